@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/gookit/color"
+	"github.com/mlange-42/dirstat/print"
+	"github.com/mlange-42/dirstat/tree"
+	"github.com/spf13/cobra"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff OLD NEW",
+	Short: "Compares two analyses, showing what grew and what shrunk.",
+	Long: `Compares two analyses, showing what grew and what shrunk.
+
+Compares an old and a new JSON file of previous analyses, or a JSON file and a directory.
+Prints added, removed, grown and shrunk entries with their old and new sizes,
+and the deltas of sizes and counts. Entries are sorted by their absolute size delta.
+
+  $ dirstat diff last-week.json .
+    (shows what changed in the current directory since the analysis in last-week.json)
+
+  $ dirstat diff old.json new.json --select src --depth 3
+    (compares sub-tree src of two analyses, to a depth of 3)
+
+  $ dirstat diff old.json new.json --json > diff.json
+    (writes the differences in JSON format)
+`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		debug, err := cmd.Flags().GetBool("debug")
+		if err != nil {
+			panic(err)
+		}
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			panic(err)
+		}
+		dirs, err := cmd.Flags().GetBool("dirs")
+		if err != nil {
+			panic(err)
+		}
+		noColors, err := cmd.Flags().GetBool("no-colors")
+		if err != nil {
+			panic(err)
+		}
+		if noColors || !color.Support256Color() || !isTerminal() {
+			color.Disable()
+		}
+
+		diff, err := runDiffCommand(cmd, args)
+		if err != nil {
+			if debug {
+				panic(err)
+			} else {
+				fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+				os.Exit(1)
+			}
+		}
+
+		if asJSON {
+			fmt.Print(print.JSONPrinter[*tree.DiffEntry]{}.Print(diff))
+		} else {
+			fmt.Print(print.NewDiffPrinter(getSizeMode(cmd), 2, dirs).Print(diff))
+		}
+	},
+}
+
+func runDiffCommand(cmd *cobra.Command, args []string) (*tree.DiffTree, error) {
+	depth, err := cmd.Flags().GetInt("depth")
+	if err != nil {
+		panic(err)
+	}
+	before, err := diffTree(cmd, args[0], depth)
+	if err != nil {
+		return nil, err
+	}
+	after, err := diffTree(cmd, args[1], depth)
+	if err != nil {
+		return nil, err
+	}
+	return tree.Diff(before, after), nil
+}
+
+// diffTree reads a JSON file or analyzes a directory, for one side of a diff
+func diffTree(cmd *cobra.Command, p string, depth int) (*tree.FileTree, error) {
+	isJSON, err := checkPath(path.Clean(p))
+	if err != nil {
+		return nil, err
+	}
+	subtree, err := cmd.Flags().GetString("select")
+	if err != nil {
+		panic(err)
+	}
+	if isJSON || len(subtree) == 0 {
+		return loadTree(cmd, []string{p}, depth, true)
+	}
+
+	// For directories, --select is applied to the full tree, as for JSON files.
+	caseSensitive, err := cmd.Flags().GetBool("case-sensitive")
+	if err != nil {
+		panic(err)
+	}
+	t, err := loadTree(cmd, []string{p}, -1, true)
+	if err != nil {
+		return nil, err
+	}
+	if t, err = selectSubTrees(t, subtree, caseSensitive); err != nil {
+		return nil, err
+	}
+	cropTree(t, depth)
+	return t, nil
+}
+
+func init() {
+	diffCmd.Flags().IntP("depth", "d", 1, "Depth of the compared file trees.\nDeeper files are included, but not individually compared.\nUse -1 for unlimited depth")
+	diffCmd.Flags().Bool("json", false, "Print the differences in JSON format")
+	diffCmd.Flags().Bool("dirs", false, "List only directories, no individual files")
+	diffCmd.Flags().Bool("apparent", false, "Compare apparent file sizes (the default).\nCombine with --disk to show both")
+	diffCmd.Flags().Bool("disk", false, "Compare sizes allocated on disk instead of apparent sizes.\nCombine with --apparent to show both")
+	diffCmd.Flags().BoolP("no-colors", "C", false, "Print without colors")
+
+	rootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+
+	"github.com/mlange-42/dirstat/filesys"
+	"github.com/mlange-42/dirstat/print"
+	"github.com/mlange-42/dirstat/util"
+	"github.com/spf13/cobra"
+)
+
+// dupesCmd represents the dupes command
+var dupesCmd = &cobra.Command{
+	Use:     "dupes",
+	Aliases: []string{"dup"},
+	Short:   "Finds duplicate files.",
+	Long: `Finds duplicate files.
+
+Files are grouped by size, and duplicates are confirmed by partial and full content hashes.
+Prints each set of duplicates with its paths and the bytes wasted by all but one copy,
+sorted by wasted bytes. Requires a directory, JSON files are not supported.
+
+  $ dirstat dupes --min-size 1M
+    (finds duplicates of at least 1 MB in the current directory)
+
+  $ dirstat dupes --json > dupes.json
+    (writes the duplicates in JSON format)
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		debug, err := cmd.Flags().GetBool("debug")
+		if err != nil {
+			panic(err)
+		}
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			panic(err)
+		}
+
+		sets, err := runDupesCommand(cmd, args)
+		if err != nil {
+			if debug {
+				panic(err)
+			} else {
+				fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+				os.Exit(1)
+			}
+		}
+
+		if asJSON {
+			fmt.Print(print.DuplicateJSONPrinter{}.Print(sets))
+		} else {
+			fmt.Print(print.DuplicatePrinter{}.Print(sets))
+		}
+	},
+}
+
+func runDupesCommand(cmd *cobra.Command, args []string) ([]filesys.DuplicateSet, error) {
+	paths, err := cmd.Flags().GetStringSlice("path")
+	if err != nil {
+		panic(err)
+	}
+	if len(paths) != 1 {
+		return nil, fmt.Errorf("dupes requires a single directory")
+	}
+	dir := path.Clean(paths[0])
+	minSizeStr, err := cmd.Flags().GetString("min-size")
+	if err != nil {
+		panic(err)
+	}
+	minSize, err := util.ParseUnits(minSizeStr, "B")
+	if err != nil {
+		return nil, fmt.Errorf("invalid --min-size: %s", err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s does not exist", dir)
+		}
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	t, err := runRootCommand(cmd, args, -1, true)
+	if err != nil {
+		return nil, err
+	}
+	if t.Value.Incomplete {
+		fmt.Fprint(os.Stderr, "WARNING: Scan incomplete, duplicates may be missing\n")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	sets, err := filesys.FindDuplicates(ctx, dir, t, minSize)
+	if err != nil && err == ctx.Err() {
+		fmt.Fprint(os.Stderr, "WARNING: Stopped (incomplete), duplicates may be missing\n")
+		return sets, nil
+	}
+	return sets, err
+}
+
+func init() {
+	dupesCmd.Flags().String("min-size", "1", "Skip files smaller than this size, like \"500k\" or \"10M\"")
+	dupesCmd.Flags().Bool("json", false, "Print the duplicates in JSON format")
+
+	rootCmd.AddCommand(dupesCmd)
+}
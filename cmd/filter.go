@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mlange-42/dirstat/tree"
+	"github.com/mlange-42/dirstat/util"
+	"github.com/spf13/cobra"
+)
+
+// addFilterFlags adds the flags for filtering the tree to a command
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().String("min-size", "", "Hide entries smaller than this apparent size, like \"500k\" or \"10M\".\nHidden entries of each directory are summarized in an entry <other>")
+	cmd.Flags().Int("min-count", 0, "Hide directories with fewer files than this.\nHidden entries of each directory are summarized in an entry <other>")
+	cmd.Flags().String("newer", "", "Hide entries not changed within this age, like \"12h\", \"7d\", \"2w\" or \"1y\".\nFor directories, the newest file counts.\nHidden entries of each directory are summarized in an entry <other>")
+	cmd.Flags().String("older", "", "Hide entries changed within this age, like \"12h\", \"7d\", \"2w\" or \"1y\".\nFor directories, the newest file counts.\nHidden entries of each directory are summarized in an entry <other>")
+}
+
+// filterTree hides the entries of t that don't match the filter flags of the command.
+// With onlyDirs, files are not filtered, as they are not listed individually.
+func filterTree(cmd *cobra.Command, t *tree.FileTree, onlyDirs bool) error {
+	minSizeStr, err := cmd.Flags().GetString("min-size")
+	if err != nil {
+		panic(err)
+	}
+	minCount, err := cmd.Flags().GetInt("min-count")
+	if err != nil {
+		panic(err)
+	}
+	if minCount < 0 {
+		return fmt.Errorf("minimum count must not be negative")
+	}
+	newerStr, err := cmd.Flags().GetString("newer")
+	if err != nil {
+		panic(err)
+	}
+	olderStr, err := cmd.Flags().GetString("older")
+	if err != nil {
+		panic(err)
+	}
+
+	var minSize int64
+	if len(minSizeStr) > 0 {
+		if minSize, err = util.ParseUnits(minSizeStr, "B"); err != nil {
+			return fmt.Errorf("invalid --min-size: %s", err)
+		}
+	}
+	var newer, older time.Duration
+	if len(newerStr) > 0 {
+		if newer, err = util.ParseAge(newerStr); err != nil {
+			return fmt.Errorf("invalid --newer: %s", err)
+		}
+	}
+	if len(olderStr) > 0 {
+		if older, err = util.ParseAge(olderStr); err != nil {
+			return fmt.Errorf("invalid --older: %s", err)
+		}
+	}
+	if minSize == 0 && minCount == 0 && newer == 0 && older == 0 {
+		return nil
+	}
+
+	tree.PruneFiles(t, func(e *tree.FileEntry, ref time.Time) bool {
+		if len(e.Unscanned) > 0 || (onlyDirs && !e.IsDir) {
+			return false
+		}
+		if e.Size < minSize || (e.IsDir && e.Count < minCount) {
+			return true
+		}
+		age := ref.Sub(e.Time)
+		return (newer > 0 && age > newer) || (older > 0 && age < older)
+	})
+	return nil
+}
@@ -18,6 +18,8 @@ var jsonCmd = &cobra.Command{
 
 Writes the result of the analysis to STDOUT in JSON format.
 When piped to a file, it can be used for visualization via the '--path' argument.
+The JSON contains both the apparent size ('size') and the size allocated on disk ('disk') of each entry.
+Files with multiple hard links are marked as 'hard_linked', and are only counted once. Sizes of further links are given as 'linked'.
 
   $ dirstat json > out.json
     (analyzes the current directory and writes JSON to out.json)
@@ -38,6 +40,9 @@ When piped to a file, it can be used for visualization via the '--path' argument
 		hasDepth := cmd.Flags().Changed("depth")
 
 		t, err := runRootCommand(cmd, args, depth, hasDepth)
+		if err == nil {
+			err = filterTree(cmd, t, false)
+		}
 		if err != nil {
 			if debug {
 				panic(err)
@@ -54,6 +59,7 @@ When piped to a file, it can be used for visualization via the '--path' argument
 
 func init() {
 	jsonCmd.Flags().IntP("depth", "d", 2, "Depth of the generated file tree.\nDeeper files are included, but not individually listed.\nUse -1 for unlimited depth (use with caution on deeply nested directory trees).\nDefaults to -1 when reading from JSON\n")
+	addFilterFlags(jsonCmd)
 
 	rootCmd.AddCommand(jsonCmd)
 }
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/mlange-42/dirstat/print"
+	"github.com/mlange-42/dirstat/tree"
+	"github.com/spf13/cobra"
+)
+
+// mergeCmd represents the merge command
+var mergeCmd = &cobra.Command{
+	Use:   "merge FILE...",
+	Short: "Merges several JSON files into one.",
+	Long: `Merges several JSON files into one.
+
+Unions the trees of several JSON files of previous analyses by path, and writes the result to STDOUT in JSON format.
+Sizes and counts of directories contained in several files are summed up.
+Files contained in several JSON files are handled according to flag --conflicts.
+
+  $ dirstat merge shard-1.json shard-2.json > all.json
+    (merges the analyses of two shards into all.json)
+
+  $ dirstat --path shard-1.json,shard-2.json
+    (merges the analyses, and prints the directory tree in text format)
+`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		debug, err := cmd.Flags().GetBool("debug")
+		if err != nil {
+			panic(err)
+		}
+
+		t, err := runMergeCommand(cmd, args)
+		if err != nil {
+			if debug {
+				panic(err)
+			} else {
+				fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+				os.Exit(1)
+			}
+		}
+
+		printer := print.JSONPrinter[*tree.FileEntry]{}
+		fmt.Print(printer.Print(t))
+	},
+}
+
+func runMergeCommand(cmd *cobra.Command, args []string) (*tree.FileTree, error) {
+	policy, err := cmd.Flags().GetString("conflicts")
+	if err != nil {
+		panic(err)
+	}
+	files := make([]string, len(args))
+	for i, arg := range args {
+		files[i] = path.Clean(arg)
+		isJSON, err := checkPath(files[i])
+		if err != nil {
+			return nil, err
+		}
+		if !isJSON {
+			return nil, fmt.Errorf("%s is not a JSON file", arg)
+		}
+	}
+	return treeFromJSON(files, policy, "", false, nil, -1)
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+}
@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"path"
-	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
 	"time"
 
@@ -36,14 +40,28 @@ To store the result of the analysis for later re-use, see subcommand 'json'.
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 
-		byExt, err := cmd.Flags().GetBool("extensions")
+		byExt, byOwner, byCategory, err := getGrouping(cmd)
 		if err != nil {
-			panic(err)
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(1)
 		}
 		sort, err := cmd.Flags().GetString("sort")
 		if err != nil {
 			panic(err)
 		}
+		ageStat, err := getAgeStat(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		showCounts, err := cmd.Flags().GetBool("counts")
+		if err != nil {
+			panic(err)
+		}
+		topFiles, err := cmd.Flags().GetInt("top-files")
+		if err != nil {
+			panic(err)
+		}
 		cutoff, err := cmd.Flags().GetFloat64("cutoff")
 		if err != nil {
 			panic(err)
@@ -61,7 +79,7 @@ To store the result of the analysis for later re-use, see subcommand 'json'.
 			panic(err)
 		}
 		hasDepth := cmd.Flags().Changed("depth")
-		if !hasDepth && byExt {
+		if !hasDepth && (byExt || byOwner || byCategory) {
 			depth = 0
 		}
 		colorExp, err := cmd.Flags().GetFloat64("exp")
@@ -91,6 +109,9 @@ To store the result of the analysis for later re-use, see subcommand 'json'.
 			}
 		}
 		t, err := runRootCommand(cmd, args, depth, true)
+		if err == nil {
+			err = filterTree(cmd, t, dirs || byExt || byOwner || byCategory)
+		}
 
 		if err != nil {
 			if debug {
@@ -101,34 +122,56 @@ To store the result of the analysis for later re-use, see subcommand 'json'.
 			}
 		}
 
-		printer := print.NewFileTreePrinter(byExt, 0.01*cutoff, 2, true, dirs, colorExp)
+		printer := print.NewFileTreePrinter(byExt, byOwner, byCategory, getSizeMode(cmd), 0.01*cutoff, 2, true, dirs, colorExp)
 		printer.SortBy = sort
+		printer.AgeStat = ageStat
+		printer.ShowCounts = showCounts
+		printer.TopFiles = topFiles
 		fmt.Print(printer.Print(t))
 	},
 }
 
 func runRootCommand(cmd *cobra.Command, args []string, depth int, hasDepth bool) (*tree.FileTree, error) {
-	dir, err := cmd.Flags().GetString("path")
+	paths, err := cmd.Flags().GetStringSlice("path")
 	if err != nil {
 		panic(err)
 	}
-	dir = path.Clean(dir)
-	info, err := os.Stat(dir)
+	return loadTree(cmd, paths, depth, hasDepth)
+}
+
+// loadTree analyzes a directory, or reads and merges JSON files, according to the flags of the command
+func loadTree(cmd *cobra.Command, paths []string, depth int, hasDepth bool) (*tree.FileTree, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no path given")
+	}
+	dir := path.Clean(paths[0])
+	isJSON, err := checkPath(dir)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("%s does not exist", dir)
-		}
 		return nil, err
 	}
-
-	isJSON := !info.IsDir() && strings.ToLower(path.Ext(info.Name())) == ".json"
-	if !info.IsDir() && !isJSON {
-		return nil, fmt.Errorf("%s is neither a directory nor a JSON file", dir)
+	if len(paths) > 1 {
+		for i, p := range paths {
+			paths[i] = path.Clean(p)
+			if isJSON, err = checkPath(paths[i]); err != nil {
+				return nil, err
+			}
+			if !isJSON {
+				return nil, fmt.Errorf("%s is not a JSON file, only JSON files can be merged", p)
+			}
+		}
+	}
+	policy, err := cmd.Flags().GetString("conflicts")
+	if err != nil {
+		panic(err)
 	}
 	exclude, err := cmd.Flags().GetStringSlice("exclude")
 	if err != nil {
 		panic(err)
 	}
+	include, err := cmd.Flags().GetStringSlice("include")
+	if err != nil {
+		panic(err)
+	}
 	quiet, err := cmd.Flags().GetBool("quiet")
 	if err != nil {
 		panic(err)
@@ -137,6 +180,67 @@ func runRootCommand(cmd *cobra.Command, args []string, depth int, hasDepth bool)
 	if err != nil {
 		panic(err)
 	}
+	workers, err := cmd.Flags().GetInt("workers")
+	if err != nil {
+		panic(err)
+	}
+	if workers < 1 {
+		return nil, fmt.Errorf("number of workers must be at least 1")
+	}
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		panic(err)
+	}
+	strict, err := cmd.Flags().GetBool("strict")
+	if err != nil {
+		panic(err)
+	}
+	followLinks, err := cmd.Flags().GetBool("follow-symlinks")
+	if err != nil {
+		panic(err)
+	}
+	oneFileSystem, err := cmd.Flags().GetBool("one-file-system")
+	if err != nil {
+		panic(err)
+	}
+	archives, err := cmd.Flags().GetBool("archives")
+	if err != nil {
+		panic(err)
+	}
+	baselineFile, err := cmd.Flags().GetString("baseline")
+	if err != nil {
+		panic(err)
+	}
+	ignore, err := getIgnoreMode(cmd)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := getCategories(cmd)
+	if err != nil {
+		return nil, err
+	}
+	sniff, err := cmd.Flags().GetBool("sniff")
+	if err != nil {
+		panic(err)
+	}
+	timeKind, err := cmd.Flags().GetString("time")
+	if err != nil {
+		panic(err)
+	}
+	topFiles, err := cmd.Flags().GetInt("top-files")
+	if err != nil {
+		panic(err)
+	}
+	if topFiles < 0 {
+		return nil, fmt.Errorf("number of top files must not be negative")
+	}
+	maxScanDepth, err := cmd.Flags().GetInt("max-scan-depth")
+	if err != nil {
+		panic(err)
+	}
+	if maxScanDepth < 0 {
+		return nil, fmt.Errorf("max scan depth must not be negative")
+	}
 	if isJSON && !hasDepth {
 		depth = -1
 	}
@@ -152,9 +256,39 @@ func runRootCommand(cmd *cobra.Command, args []string, depth int, hasDepth bool)
 		if serr != nil {
 			panic(serr)
 		}
-		t, err = treeFromJSON(dir, subtree, exclude, depth)
+		caseSensitive, serr := cmd.Flags().GetBool("case-sensitive")
+		if serr != nil {
+			panic(serr)
+		}
+		t, err = treeFromJSON(paths, policy, subtree, caseSensitive, exclude, depth)
+		if err == nil && cmd.Flags().Changed("time") && jsonTimeKind(t) != timeKind {
+			err = fmt.Errorf("can't use --time %s, JSON file contains %s", timeKind, jsonTimeKind(t))
+		}
 	} else {
-		t, err = treeFromDir(dir, exclude, depth, quiet)
+		opts := filesys.Options{
+			Exclude:       exclude,
+			Include:       include,
+			MaxDepth:      depth,
+			MaxScanDepth:  maxScanDepth,
+			Workers:       workers,
+			Strict:        strict,
+			FollowLinks:   followLinks,
+			OneFileSystem: oneFileSystem,
+			Ignore:        ignore,
+			Archives:      archives,
+			Categories:    categories,
+			Sniff:         sniff,
+			Time:          timeKind,
+			TopFiles:      topFiles,
+		}
+		if len(baselineFile) > 0 {
+			baseline, berr := treeFromJSON([]string{baselineFile}, tree.MergeSum, "", false, nil, -1)
+			if berr != nil {
+				return nil, fmt.Errorf("can't read baseline: %s", berr)
+			}
+			opts.Baseline = filesys.NewBaseline(baseline)
+		}
+		t, err = treeFromDir(dir, opts, timeout, quiet)
 	}
 	if err != nil {
 		return nil, err
@@ -162,8 +296,16 @@ func runRootCommand(cmd *cobra.Command, args []string, depth int, hasDepth bool)
 	return t, nil
 }
 
-func treeFromDir(dir string, exclude []string, depth int, quiet bool) (*tree.FileTree, error) {
-	progress := make(chan int64, 32)
+func treeFromDir(dir string, opts filesys.Options, timeout time.Duration, quiet bool) (*tree.FileTree, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	progress := make(chan filesys.Progress, 32)
 	done := make(chan *tree.Tree[*tree.FileEntry])
 	erro := make(chan error)
 
@@ -171,28 +313,42 @@ func treeFromDir(dir string, exclude []string, depth int, quiet bool) (*tree.Fil
 	var err error = nil
 	var size int64 = 0
 	var count int = 0
+	var skipped int = 0
 	minElapsed := 250 * time.Millisecond
 
-	go filesys.Walk(dir, exclude, depth, progress, done, erro)
+	go filesys.Walk(ctx, dir, opts, progress, done, erro)
 
 	startTime := time.Now()
 	prevTime := startTime
+	cancelled := ctx.Done()
 
 Loop:
 	for {
 		select {
 		case p := <-progress:
-			size += p
+			if p.Skipped {
+				skipped++
+				continue
+			}
+			size += p.Size
 			count++
 			if !quiet {
 				if count%10 == 0 && time.Since(prevTime) >= minElapsed {
 					prevTime = time.Now()
-					fmt.Fprintf(os.Stderr, "\rScan: %6s, %d files in %s    ", util.FormatUnits(size, "B"), count, time.Since(startTime).Round(time.Millisecond))
+					fmt.Fprintf(os.Stderr, "\rScan: %6s, %d files%s in %s    ", util.FormatUnits(size, "B"), count, skippedInfo(skipped), time.Since(startTime).Round(time.Millisecond))
 				}
 			}
+		case <-cancelled:
+			// Restore default signal handling, so that a second interrupt terminates immediately.
+			stop()
+			cancelled = nil
 		case t = <-done:
 			if !quiet {
-				fmt.Fprintf(os.Stderr, "\rDone: %6s, %d (%s) files in %s    \n", util.FormatUnits(size, "B"), count, util.FormatUnitsSimple(int64(count), ""), time.Since(startTime).Round(time.Millisecond))
+				status := "Done"
+				if ctx.Err() != nil {
+					status = "Stopped (incomplete)"
+				}
+				fmt.Fprintf(os.Stderr, "\r%s: %6s, %d (%s) files%s%s%s in %s    \n", status, util.FormatUnits(size, "B"), count, util.FormatUnitsSimple(int64(count), ""), linkedInfo(t.Value.Linked), skippedInfo(skipped), baselineInfo(opts.Baseline), time.Since(startTime).Round(time.Millisecond))
 			}
 			break Loop
 		case err = <-erro:
@@ -203,35 +359,283 @@ Loop:
 	return t, err
 }
 
-func treeFromJSON(file string, subtree string, exclude []string, depth int) (*tree.FileTree, error) {
-	bytes, err := ioutil.ReadFile(file)
+// skippedInfo formats the number of skipped paths for progress output
+func skippedInfo(skipped int) string {
+	if skipped == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", %d skipped", skipped)
+}
+
+// baselineInfo formats the number of directories reused from the baseline for progress output
+func baselineInfo(baseline *filesys.Baseline) string {
+	if baseline == nil {
+		return ""
+	}
+	return fmt.Sprintf(", %d directories reused, %d rescanned", baseline.Reused(), baseline.Rescanned())
+}
+
+// linkedInfo formats the size deduplicated as hard links for progress output
+func linkedInfo(linked int64) string {
+	if linked == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", %s in hard links", util.FormatUnitsSimple(linked, "B"))
+}
+
+// checkPath checks that a path is a directory or a JSON file, and returns whether it is a JSON file
+func checkPath(p string) (bool, error) {
+	info, err := os.Stat(p)
 	if err != nil {
-		return nil, err
+		if os.IsNotExist(err) {
+			return false, fmt.Errorf("%s does not exist", p)
+		}
+		return false, err
+	}
+	isJSON := !info.IsDir() && strings.ToLower(path.Ext(info.Name())) == ".json"
+	if !info.IsDir() && !isJSON {
+		return false, fmt.Errorf("%s is neither a directory nor a JSON file", p)
 	}
-	t, err := tree.Deserialize(bytes)
+	return isJSON, nil
+}
+
+// treeFromJSON reads trees from JSON files, and merges them using policy for conflicting files
+func treeFromJSON(files []string, policy string, subtree string, caseSensitive bool, exclude []string, depth int) (*tree.FileTree, error) {
+	trees := make([]*tree.FileTree, len(files))
+	for i, file := range files {
+		bytes, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		trees[i], err = tree.Deserialize(bytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	t, err := tree.Merge(policy, trees...)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(subtree) != 0 {
-		elems := strings.Split(filepath.ToSlash(subtree), "/")
-		t, err = tree.SubTree(t, elems, func(e *tree.FileEntry, path string) bool {
-			return strings.ToLower(e.Name) == strings.ToLower(path)
-		})
+		t, err = selectSubTrees(t, subtree, caseSensitive)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	if depth >= 0 {
-		t.Crop(depth, func(parent, child *tree.FileEntry) {
-			if child.IsDir {
-				parent.AddExtensions(child.Extensions)
+	cropTree(t, depth)
+	return t, nil
+}
+
+// cropTree reduces a tree to the given depth, if it is not negative
+func cropTree(t *tree.FileTree, depth int) {
+	if depth < 0 {
+		return
+	}
+	t.Crop(depth, func(parent, child *tree.FileEntry) {
+		if child.IsDir {
+			parent.AddExtensions(child.Extensions)
+			parent.AddOwners(child.Owners)
+			parent.AddCategories(child.Categories)
+		}
+		parent.Errors = append(parent.Errors, child.Errors...)
+	})
+}
+
+// selectSubTrees selects the sub-trees at path, which may contain glob patterns.
+// Several matches are combined under a new root named by the path, with their paths as names.
+func selectSubTrees(t *tree.FileTree, path string, caseSensitive bool) (*tree.FileTree, error) {
+	matches, err := tree.FindFiles(t, path, caseSensitive)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("Can't select sub-tree. Nothing found at path '%s'", path)
+	}
+	root := t.Value
+	scanTime := time.Now()
+	if root.ScanTime != nil {
+		scanTime = *root.ScanTime
+	}
+
+	var result *tree.FileTree
+	if len(matches) == 1 {
+		result = matches[0]
+		result.Value.Largest = selectLargest(root.Largest, tree.FilePath(result))
+		result.Detach()
+	} else {
+		result = tree.NewDir(path)
+		for _, m := range matches {
+			p := tree.FilePath(m)
+			for _, f := range selectLargest(root.Largest, p) {
+				f.Path = p + "/" + f.Path
+				result.Value.Largest = append(result.Value.Largest, f)
+			}
+			m.Detach()
+			m.Value.Name = p
+			result.AddTree(m)
+
+			v := m.Value
+			result.Value.Add(v.Size, v.Disk, v.Count, v.Time)
+			if v.IsDir {
+				result.Value.AddAges(v.Oldest, v.Ages)
+			} else {
+				// Like in walked trees, directories contain the extensions, owners and categories of their files.
+				// Those of matched directories are folded in when cropping or printing.
+				result.Value.AddAge(v.Time, scanTime)
+				addFileEntries(result.Value, v, scanTime)
 			}
+			result.Value.AddCounts(v)
+			result.Value.Linked += v.Linked
+			result.Value.Incomplete = result.Value.Incomplete || v.Incomplete
+		}
+		sort.SliceStable(result.Value.Largest, func(i, j int) bool {
+			return result.Value.Largest[i].Size > result.Value.Largest[j].Size
 		})
 	}
+	result.Value.TimeKind = root.TimeKind
+	result.Value.ScanTime = root.ScanTime
+	return result, nil
+}
 
-	return t, nil
+// addFileEntries adds file f to the extensions, owners and categories of directory dir.
+// Ages are relative to scanTime, the time of the analysis.
+func addFileEntries(dir *tree.FileEntry, f *tree.FileEntry, scanTime time.Time) {
+	entries := func(name string) map[string]*tree.ExtensionEntry {
+		e := &tree.ExtensionEntry{Name: name, Size: f.Size, Disk: f.Disk, Count: f.Count, Time: f.Time}
+		e.AddAge(f.Time, scanTime)
+		return map[string]*tree.ExtensionEntry{name: e}
+	}
+	dir.AddExtensions(entries(path.Ext(f.Name)))
+	if len(f.Owner) > 0 {
+		dir.AddOwners(entries(f.Owner))
+	}
+	if len(f.Category) > 0 {
+		dir.AddCategories(entries(f.Category))
+	}
+}
+
+// selectLargest returns the largest files inside the sub-tree at path, with paths relative to the sub-tree
+func selectLargest(files []tree.LargeFile, path string) []tree.LargeFile {
+	prefix := path + "/"
+	var result []tree.LargeFile
+	for _, f := range files {
+		if strings.HasPrefix(f.Path, prefix) {
+			f.Path = f.Path[len(prefix):]
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// jsonTimeKind returns the kind of file timestamps in a tree read from JSON.
+// Older JSON files without it contain modification times
+func jsonTimeKind(t *tree.FileTree) string {
+	if len(t.Value.TimeKind) == 0 {
+		return tree.TimeModified
+	}
+	return t.Value.TimeKind
+}
+
+// getSizeMode determines the size mode from flags --apparent and --disk
+func getSizeMode(cmd *cobra.Command) string {
+	apparent, err := cmd.Flags().GetBool("apparent")
+	if err != nil {
+		panic(err)
+	}
+	disk, err := cmd.Flags().GetBool("disk")
+	if err != nil {
+		panic(err)
+	}
+	switch {
+	case apparent && disk:
+		return print.SizeBoth
+	case disk:
+		return print.SizeDisk
+	default:
+		return print.SizeApparent
+	}
+}
+
+// getAgeStat determines which age statistic of directories is shown, from flag --age
+func getAgeStat(cmd *cobra.Command) (string, error) {
+	stat, err := cmd.Flags().GetString("age")
+	if err != nil {
+		panic(err)
+	}
+	if stat != print.AgeNewest && stat != print.AgeOldest && stat != print.AgeMedian {
+		return "", fmt.Errorf("unknown age statistic '%s'. Must be one of [newest, oldest, median]", stat)
+	}
+	return stat, nil
+}
+
+// getIgnoreMode determines how to use ignore files from the flags --gitignore and --only-ignored
+func getIgnoreMode(cmd *cobra.Command) (filesys.IgnoreMode, error) {
+	respect, err := cmd.Flags().GetBool("gitignore")
+	if err != nil {
+		panic(err)
+	}
+	invert, err := cmd.Flags().GetBool("only-ignored")
+	if err != nil {
+		panic(err)
+	}
+	switch {
+	case respect && invert:
+		return filesys.IgnoreOff, fmt.Errorf("flags --gitignore and --only-ignored can't be used together")
+	case respect:
+		return filesys.IgnoreRespect, nil
+	case invert:
+		return filesys.IgnoreInvert, nil
+	default:
+		return filesys.IgnoreOff, nil
+	}
+}
+
+// getGrouping determines how to group directory content from the flags --extensions, --by-owner and --by-category
+func getGrouping(cmd *cobra.Command) (byExt bool, byOwner bool, byCategory bool, err error) {
+	byExt, err = cmd.Flags().GetBool("extensions")
+	if err != nil {
+		panic(err)
+	}
+	byOwner, err = cmd.Flags().GetBool("by-owner")
+	if err != nil {
+		panic(err)
+	}
+	byCategory, err = cmd.Flags().GetBool("by-category")
+	if err != nil {
+		panic(err)
+	}
+	count := 0
+	for _, b := range []bool{byExt, byOwner, byCategory} {
+		if b {
+			count++
+		}
+	}
+	if count > 1 {
+		return false, false, false, fmt.Errorf("flags --extensions, --by-owner and --by-category can't be used together")
+	}
+	return byExt, byOwner, byCategory, nil
+}
+
+// getCategories creates the file categories from the defaults and the file given by flag --categories
+func getCategories(cmd *cobra.Command) (*filesys.Categories, error) {
+	file, err := cmd.Flags().GetString("categories")
+	if err != nil {
+		panic(err)
+	}
+	if len(file) == 0 {
+		return filesys.NewCategories(filesys.DefaultCategories()), nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("can't read categories: %s", err)
+	}
+	user := map[string]filesys.CategoryDef{}
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, fmt.Errorf("can't read categories: %s", err)
+	}
+	return filesys.NewCategories(filesys.DefaultCategories(), user), nil
 }
 
 func isTerminal() bool {
@@ -248,18 +652,42 @@ func Execute() {
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringP("path", "p", ".", "Path to scan or JSON file to load")
-	rootCmd.PersistentFlags().String("select", "", "When reading from JSON, use only this sub-tree")
-	rootCmd.PersistentFlags().StringSliceP("exclude", "e", []string{}, "Exclusion glob patterns. Ignored when reading from JSON.\nRequires a comma-separated list of patterns, like \"*.exe,.git\"")
+	rootCmd.PersistentFlags().StringSliceP("path", "p", []string{"."}, "Path to scan or JSON file to load.\nSeveral JSON files are merged, like \"a.json,b.json\"")
+	rootCmd.PersistentFlags().String("conflicts", tree.MergeSum, "How to merge files contained in several JSON files, one of [sum, first, last, largest, newest].\nSum treats them as distinct files, the others keep only one of them")
+	rootCmd.PersistentFlags().String("select", "", "When reading from JSON, use only this sub-tree, like \"src/cmd\".\nPath elements can be glob patterns, like \"src/*/testdata\".\nSeveral matching sub-trees are shown together, with their paths")
+	rootCmd.PersistentFlags().Bool("case-sensitive", false, "Match paths of --select case-sensitively")
+	rootCmd.PersistentFlags().StringSliceP("exclude", "e", []string{}, "Gitignore-style exclusion patterns. Ignored when reading from JSON.\nRequires a comma-separated list of patterns, like \"*.exe,.git,build/tmp\".\nPatterns with a slash are matched against the path relative to the scanned directory.\nSupports '**' for any number of directories, and '!' for negation")
+	rootCmd.PersistentFlags().StringSliceP("include", "i", []string{}, "Gitignore-style inclusion patterns for files. Ignored when reading from JSON.\nOnly files matching these patterns are included, like \"*.go,docs/**\".\nSupports the same syntax as --exclude")
+	rootCmd.PersistentFlags().Int("workers", runtime.NumCPU(), "Number of directories to read concurrently.\nUse 1 for a sequential scan")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Stop the scan after the given duration, like \"30s\" or \"5m\", and use the partial result.\nThe scan can also be stopped with Ctrl-C")
+	rootCmd.PersistentFlags().Bool("follow-symlinks", false, "Follow symbolic links. Links resulting in a cycle are not followed.\nFiles reached through several paths are only charged once, preferably to a path without links.\nBy default, links are listed with their target, but not followed")
+	rootCmd.PersistentFlags().Int("max-scan-depth", 0, "Don't read directories at this depth, for a quick overview of large trees.\nThese are listed as placeholders with unknown size '?'.\nUse 0 for unlimited depth. Ignored when reading from JSON")
+	rootCmd.PersistentFlags().Bool("one-file-system", false, "Don't descend into directories on other file systems, like mount points.\nThese are listed as placeholders")
+	rootCmd.PersistentFlags().Bool("archives", false, "Show the content of zip, jar, tar and tar.gz archives as virtual directories.\nInside archives, the apparent size is the uncompressed size,\nand the disk size is the compressed size. Use --apparent --disk to show both")
+	rootCmd.PersistentFlags().String("baseline", "", "JSON file of a previous analysis of the same directory, for an incremental rescan.\nListings of directories with an unchanged modification time are reused from it.\nRequires a JSON with unlimited depth (dirstat json -d -1) to reuse all directories")
+	rootCmd.PersistentFlags().String("time", tree.TimeModified, "Timestamp of files for ages, age sorting and colors, one of [mtime, atime, ctime, btime].\nFor modification, access, change (metadata) or birth (creation) time.\nFalls back to the modification time where the timestamp is not available,\nlike inside zip archives for all but the modification time")
+	rootCmd.PersistentFlags().Int("top-files", 0, "Record the given number of largest files anywhere in the tree, independent of --depth.\nLists them below the directory tree, and includes them in JSON")
+	rootCmd.PersistentFlags().Bool("sniff", false, "Detect file types from the first 512 bytes of each file, for categories.\nBy default, files are categorized by their extension only")
+	rootCmd.PersistentFlags().String("categories", "", "JSON file with custom file categories, like\n{\"models\": {\"extensions\": [\".onnx\"], \"mime\": [\"application/x-hdf\"]}}.\nMIME types ending with a slash match all sub-types.\nExtends and overrides the default categories")
+	rootCmd.PersistentFlags().Bool("gitignore", false, "Skip files and directories ignored by .gitignore and .dirstatignore files in the scanned tree.\nAlso skips .git directories")
+	rootCmd.PersistentFlags().Bool("only-ignored", false, "Show only files and directories ignored by .gitignore and .dirstatignore files,\nlike build outputs and dependencies")
+	rootCmd.PersistentFlags().Bool("strict", false, "Abort on the first path or archive that can't be read.\nBy default, unreadable paths are skipped and recorded,\nand unreadable archives are counted as files")
 	rootCmd.PersistentFlags().Bool("debug", false, "Debug mode with error traces")
 	rootCmd.PersistentFlags().Bool("quiet", false, "Don't show progress on stderr")
 	rootCmd.PersistentFlags().Bool("profile", false, "Do CPU profiling of the analysis part")
 
 	rootCmd.Flags().IntP("depth", "d", 1, "Depth of the generated file tree.\nDeeper files are included, but not individually listed.\nUse -1 for unlimited depth (use with caution on deeply nested directory trees).\nDefaults to -1 when reading from JSON\n")
 	rootCmd.Flags().BoolP("extensions", "x", false, "Show directory content by file extension instead of individual files")
+	rootCmd.Flags().Bool("by-owner", false, "Show directory content by owner (user:group) instead of individual files")
+	rootCmd.Flags().Bool("by-category", false, "Show directory content by file category, like images or source, instead of individual files")
 	rootCmd.Flags().StringP("sort", "s", "name", "Sort by one of [name, size, count, age]")
+	rootCmd.Flags().String("age", print.AgeNewest, "Age statistic of directories shown, coloured and sorted by, one of [newest, oldest, median].\nThe median is approximated from a histogram of file ages")
 	rootCmd.Flags().Float64P("cutoff", "c", 100.0, "Only show the given top percent when sorted by size or count.\nIgnored otherwise")
+	rootCmd.Flags().Bool("counts", false, "Show the numbers of directories (dir), empty directories (emp),\nsymbolic links (lnk) and special files (spc) like pipes, sockets and devices")
 	rootCmd.Flags().Bool("dirs", false, "List only directories, no individual files")
+	rootCmd.Flags().Bool("apparent", false, "Show apparent file sizes (the default).\nCombine with --disk to show both")
+	rootCmd.Flags().Bool("disk", false, "Show sizes allocated on disk instead of apparent sizes.\nCombine with --apparent to show both")
 	rootCmd.Flags().Float64("exp", 5.0, "Color scale exponent.\n1.0 is linear. Higher values look more log-like.")
 	rootCmd.Flags().BoolP("no-colors", "C", false, "Print without colors")
+	addFilterFlags(rootCmd)
 }
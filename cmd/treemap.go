@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"image/color"
 	"os"
+	"strings"
 
 	"github.com/mlange-42/dirstat/print"
 	"github.com/nikolaydubina/treemap"
 	"github.com/nikolaydubina/treemap/parser"
 	"github.com/nikolaydubina/treemap/render"
 	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
 )
 
 // treemapCmd represents the treemap command
@@ -26,18 +28,32 @@ Immediately open the created SVG with the default associated program (ideally a
   $ dirstat treemap > out.svg && out.svg
 	`,
 	Run: func(cmd *cobra.Command, args []string) {
-		byExt, err := cmd.Flags().GetBool("extensions")
+		byExt, byOwner, byCategory, err := getGrouping(cmd)
 		if err != nil {
-			panic(err)
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(1)
 		}
 		byCount, err := cmd.Flags().GetBool("count")
 		if err != nil {
 			panic(err)
 		}
+		countMode, err := cmd.Flags().GetString("count-mode")
+		if err != nil {
+			panic(err)
+		}
+		if !slices.Contains(print.CountModes, countMode) {
+			fmt.Fprintf(os.Stderr, "ERROR: Unknown count mode '%s'. Must be one of [%s]\n", countMode, strings.Join(print.CountModes, ", "))
+			os.Exit(1)
+		}
 		colAge, err := cmd.Flags().GetBool("mod")
 		if err != nil {
 			panic(err)
 		}
+		ageStat, err := getAgeStat(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(1)
+		}
 		dirs, err := cmd.Flags().GetBool("dirs")
 		if err != nil {
 			panic(err)
@@ -60,6 +76,9 @@ Immediately open the created SVG with the default associated program (ideally a
 		hasDepth := cmd.Flags().Changed("depth")
 
 		t, err := runRootCommand(cmd, args, depth, hasDepth)
+		if err == nil {
+			err = filterTree(cmd, t, dirs || byExt || byOwner || byCategory)
+		}
 		if err != nil {
 			if debug {
 				panic(err)
@@ -69,7 +88,9 @@ Immediately open the created SVG with the default associated program (ideally a
 			}
 		}
 
-		printer := print.NewTreemapPrinter(byExt, byCount, colAge, dirs)
+		printer := print.NewTreemapPrinter(byExt, byOwner, byCategory, byCount, colAge, dirs, getSizeMode(cmd))
+		printer.AgeStat = ageStat
+		printer.CountMode = countMode
 		str := printer.Print(t)
 		if csv {
 			fmt.Print(str)
@@ -168,9 +189,16 @@ func init() {
 	treemapCmd.Flags().IntP("depth", "d", 2, "Depth of the generated file tree.\nDeeper files are included, but not individually listed.\nUse -1 for unlimited depth (use with caution on deeply nested directory trees).\nDefaults to -1 when reading from JSON\n")
 	treemapCmd.Flags().Bool("csv", false, "Generate raw CSV output for github.com/nikolaydubina/treemap")
 	treemapCmd.Flags().BoolP("extensions", "x", false, "Show directory content by file extension instead of individual files")
+	treemapCmd.Flags().Bool("by-owner", false, "Show directory content by owner (user:group) instead of individual files")
+	treemapCmd.Flags().Bool("by-category", false, "Show directory content by file category, like images or source, instead of individual files")
 	treemapCmd.Flags().BoolP("count", "c", false, "Size boxes by file count instead of disk memory")
-	treemapCmd.Flags().BoolP("mod", "m", false, "Color boxes by last file modification")
+	treemapCmd.Flags().String("count-mode", print.CountFiles, "What to count with --count, one of [files, dirs, inodes, empty, links, special].\nFiles includes links and special files, inodes includes files and directories")
+	treemapCmd.Flags().BoolP("mod", "m", false, "Color boxes by age, using the timestamp selected by --time and the statistic selected by --age")
+	treemapCmd.Flags().String("age", print.AgeNewest, "Age statistic of directories for colors with --mod, one of [newest, oldest, median].\nThe median is approximated from a histogram of file ages")
 	treemapCmd.Flags().Bool("dirs", false, "List only directories, no individual files")
+	treemapCmd.Flags().Bool("apparent", false, "Size boxes by apparent file sizes (the default).\nCombine with --disk to label boxes with both sizes")
+	treemapCmd.Flags().Bool("disk", false, "Size boxes by sizes allocated on disk instead of apparent sizes.\nCombine with --apparent to label boxes with both sizes")
+	addFilterFlags(treemapCmd)
 
 	treemapCmd.Flags().Float64("w", 1028, "width of output")
 	treemapCmd.Flags().Float64("h", 640, "height of output")
@@ -0,0 +1,293 @@
+package filesys
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mlange-42/dirstat/tree"
+)
+
+// archiveSuffixes maps file name suffixes to archive formats
+var archiveSuffixes = []struct {
+	suffix string
+	format string
+}{
+	{".zip", tree.ArchiveZip},
+	{".jar", tree.ArchiveZip},
+	{".tar", tree.ArchiveTar},
+	{".tar.gz", tree.ArchiveTarGz},
+	{".tgz", tree.ArchiveTarGz},
+}
+
+// archiveFormat returns the archive format of a file, by its name.
+// Returns an empty string for files that are no supported archives.
+func archiveFormat(name string) string {
+	name = strings.ToLower(name)
+	for _, s := range archiveSuffixes {
+		if strings.HasSuffix(name, s.suffix) {
+			return s.format
+		}
+	}
+	return ""
+}
+
+// archiveEntry is a file or directory inside an archive
+type archiveEntry struct {
+	path   string
+	isDir  bool
+	size   int64
+	packed int64
+	time   time.Time // Modification time
+	atime  time.Time // Access time. Zero if not recorded
+	ctime  time.Time // Change time. Zero if not recorded
+	link   string
+}
+
+// timestamp returns the timestamp of the entry selected by kind.
+// Falls back to the modification time if the archive does not record it.
+// Zip archives only record modification times, and no archive records birth times.
+func (e *archiveEntry) timestamp(kind string) time.Time {
+	t := e.time
+	switch kind {
+	case tree.TimeAccessed:
+		t = e.atime
+	case tree.TimeChanged:
+		t = e.ctime
+	}
+	if t.IsZero() {
+		return e.time
+	}
+	return t
+}
+
+// readArchive lists the entries of an archive of size bytes.
+// On error, it returns the entries read before the error.
+//
+// For tar archives, compressed sizes are not stored per entry.
+// They are estimated from the share of the entry in the total uncompressed size.
+func readArchive(ctx context.Context, fsys fileSystem, name string, format string, size int64) ([]archiveEntry, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if format == tree.ArchiveZip {
+		return readZip(ctx, f, size)
+	}
+
+	var r io.Reader = f
+	if format == tree.ArchiveTarGz {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	entries, err := readTar(ctx, r)
+
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+	if total > 0 {
+		ratio := float64(size) / float64(total)
+		for i := range entries {
+			entries[i].packed = int64(float64(entries[i].size) * ratio)
+		}
+	}
+	return entries, err
+}
+
+// readZip lists the entries of a zip archive.
+// Files without random access are spooled to a temporary file first
+func readZip(ctx context.Context, f fs.File, size int64) ([]archiveEntry, error) {
+	ra, ok := f.(io.ReaderAt)
+	if !ok {
+		tmp, err := spool(f)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		ra = tmp
+	}
+	r, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]archiveEntry, 0, len(r.File))
+	for _, zf := range r.File {
+		if err := ctx.Err(); err != nil {
+			return entries, err
+		}
+		entries = append(entries, archiveEntry{
+			path:   zf.Name,
+			isDir:  strings.HasSuffix(zf.Name, "/"),
+			size:   int64(zf.UncompressedSize64),
+			packed: int64(zf.CompressedSize64),
+			time:   zf.Modified,
+		})
+	}
+	return entries, nil
+}
+
+// spool copies r to a new temporary file, which is removed on error.
+// Otherwise, it must be closed and removed by the caller
+func spool(r io.Reader) (*os.File, error) {
+	tmp, err := os.CreateTemp("", "dirstat-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return tmp, nil
+}
+
+// readTar lists the entries of a tar archive, without compressed sizes
+func readTar(ctx context.Context, r io.Reader) ([]archiveEntry, error) {
+	tr := tar.NewReader(r)
+	entries := []archiveEntry{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return entries, err
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+		info := hdr.FileInfo()
+		switch {
+		case info.IsDir():
+			entries = append(entries, archiveEntry{path: hdr.Name, isDir: true})
+		case info.Mode().IsRegular():
+			entries = append(entries, archiveEntry{path: hdr.Name, size: hdr.Size, time: hdr.ModTime, atime: hdr.AccessTime, ctime: hdr.ChangeTime})
+		case len(hdr.Linkname) > 0:
+			entries = append(entries, archiveEntry{path: hdr.Name, time: hdr.ModTime, atime: hdr.AccessTime, ctime: hdr.ChangeTime, link: hdr.Linkname})
+		}
+	}
+}
+
+// archivedEntry is a file entry with the content of an archive, read by a worker
+type archivedEntry struct {
+	fs.DirEntry
+	entries []archiveEntry
+	err     error
+}
+
+// readArchives wraps archives in entries by entries with their content,
+// so that the potentially slow reads happen in the worker.
+// Links are kept identifiable, and hard-linked archives are not read, as they are not expanded.
+func readArchives(ctx context.Context, fsys fileSystem, dirname string, entries []fs.DirEntry) {
+	for i, e := range entries {
+		format := archiveFormat(e.Name())
+		if _, ok := e.(*linkDirEntry); ok || len(format) == 0 {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if _, ok := hardLinkID(info); ok {
+			continue
+		}
+		archive, err := readArchive(ctx, fsys, fsys.Join(dirname, e.Name()), format, info.Size())
+		entries[i] = &archivedEntry{e, archive, err}
+	}
+}
+
+// archiveContent returns the entries of archive d at path, as read by a worker, or reads them
+func archiveContent(ctx context.Context, fsys fileSystem, path string, d fs.DirEntry, format string, size int64) ([]archiveEntry, error) {
+	if a, ok := d.(*archivedEntry); ok {
+		return a.entries, a.err
+	}
+	return readArchive(ctx, fsys, path, format, size)
+}
+
+// addArchive adds the entries of an archive to node, like the walker adds files and directories.
+// Node is the archive's own node at depth, or the deepest listed ancestor if the archive is deeper than maxDepth.
+// Entries are charged to the archive's owner, and categorized by cats from their extensions.
+// Disk is the archive's size on disk. Its overhead over the entries' compressed sizes is charged to node.
+// Timestamps of entries are selected by timeKind, see archiveEntry.timestamp. Ages are relative to scanTime, the time of the analysis.
+func addArchive(node *tree.FileTree, entries []archiveEntry, owner string, cats *Categories, depth int, maxDepth int, disk int64, timeKind string, scanTime time.Time) {
+	type dirNode struct {
+		node  *tree.FileTree
+		depth int
+	}
+	dirs := map[string]dirNode{"": {node, depth}}
+	// dir returns the node for a directory and its depth, creating it and its parents if required.
+	// Directories deeper than maxDepth are folded into their deepest listed ancestor.
+	var dir func(p string) dirNode
+	dir = func(p string) dirNode {
+		if n, ok := dirs[p]; ok {
+			return n
+		}
+		n := dir(parentPath(p))
+		if maxDepth < 0 || n.depth < maxDepth {
+			sub := tree.NewDir(path.Base(p))
+			n.node.AddTree(sub)
+			n = dirNode{sub, n.depth + 1}
+		}
+		dirs[p] = n
+		return n
+	}
+
+	var packed int64
+	for _, e := range entries {
+		p := strings.Trim(path.Clean("/"+e.path), "/")
+		if len(p) == 0 {
+			continue
+		}
+		packed += e.packed
+		if e.isDir {
+			dir(p)
+			continue
+		}
+		n := dir(parentPath(p))
+		name := path.Base(p)
+
+		file := tree.NewFileEntry(name, e.size, e.packed, e.timestamp(timeKind), false)
+		file.Link, file.Owner, file.Category = e.link, owner, cats.category(name, "")
+		if len(e.link) > 0 {
+			file.Links = 1
+		}
+		addFile(n.node.Value, &file, scanTime)
+
+		if maxDepth < 0 || n.depth < maxDepth {
+			n.node.AddTree(tree.New(&file))
+		}
+	}
+	if disk > packed {
+		node.Value.Disk += disk - packed
+	}
+	if node.Value.Archive != "" {
+		// Like readDir for the entries of directories.
+		tree.SortChildren(node)
+	}
+}
+
+// parentPath returns the parent of a slash-separated path inside an archive,
+// or an empty string for the archive root
+func parentPath(p string) string {
+	i := strings.LastIndexByte(p, '/')
+	if i < 0 {
+		return ""
+	}
+	return p[:i]
+}
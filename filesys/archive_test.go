@@ -0,0 +1,181 @@
+package filesys
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/mlange-42/dirstat/tree"
+	"github.com/stretchr/testify/assert"
+)
+
+// createZip creates a zip archive with files of the given sizes
+func createZip(t *testing.T, files map[string]int) []byte {
+	buf := bytes.Buffer{}
+	w := zip.NewWriter(&buf)
+	for name, size := range files {
+		f, err := w.Create(name)
+		assert.Nil(t, err)
+		_, err = f.Write(make([]byte, size))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, w.Close())
+	return buf.Bytes()
+}
+
+// createTarGz creates a gzipped tar archive with files of the given sizes
+func createTarGz(t *testing.T, files map[string]int) []byte {
+	buf := bytes.Buffer{}
+	gz := gzip.NewWriter(&buf)
+	w := tar.NewWriter(gz)
+	for name, size := range files {
+		assert.Nil(t, w.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(size), Typeflag: tar.TypeReg}))
+		_, err := w.Write(make([]byte, size))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, w.Close())
+	assert.Nil(t, gz.Close())
+	return buf.Bytes()
+}
+
+// streamFS is a file system with files that can only be read sequentially
+type streamFS struct {
+	fstest.MapFS
+}
+
+func (f streamFS) Open(name string) (fs.File, error) {
+	file, err := f.MapFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ fs.File }{file}, nil
+}
+
+func TestWalkArchives(t *testing.T) {
+	files := map[string]int{"a.txt": 100, "lib/b.bin": 2000, "lib/sub/c.bin": 3000}
+	zipData := createZip(t, files)
+	tarData := createTarGz(t, files)
+	fsys := fstest.MapFS{
+		"bundle.zip":    &fstest.MapFile{Data: zipData},
+		"dist/app.tgz":  &fstest.MapFile{Data: tarData},
+		"dist/fake.jar": &fstest.MapFile{Data: []byte("no archive")},
+	}
+
+	for _, workers := range []int{1, 4} {
+		tr, err := walkFS(context.Background(), fsys, ".", Options{MaxDepth: -1, Workers: workers, Archives: true})
+		assert.Nil(t, err)
+
+		zipTree := child(t, tr, "bundle.zip")
+		assert.True(t, zipTree.Value.IsDir)
+		assert.Equal(t, tree.ArchiveZip, zipTree.Value.Archive)
+		assert.Equal(t, 3, zipTree.Value.Count)
+		assert.Equal(t, int64(5100), zipTree.Value.Size)
+		assert.Equal(t, int64(len(zipData)), zipTree.Value.Disk)
+		assert.Equal(t, []string{"lib", "a.txt"}, names(zipTree))
+		assert.Equal(t, int64(3000), child(t, zipTree, "lib", "sub", "c.bin").Value.Size)
+
+		tarTree := child(t, tr, "dist", "app.tgz")
+		assert.Equal(t, tree.ArchiveTarGz, tarTree.Value.Archive)
+		assert.Equal(t, int64(5100), tarTree.Value.Size)
+		assert.InDelta(t, len(tarData), tarTree.Value.Disk, 1)
+
+		fake := child(t, tr, "dist", "fake.jar")
+		assert.False(t, fake.Value.IsDir)
+		assert.Equal(t, int64(10), fake.Value.Size)
+		assert.Equal(t, []tree.PathError{{Path: "dist/fake.jar", Kind: tree.ErrorOther}}, child(t, tr, "dist").Value.Errors)
+
+		assert.Equal(t, 3+3+1, tr.Value.Count)
+		assert.Equal(t, int64(5100+5100+10), tr.Value.Size)
+	}
+
+	for _, workers := range []int{1, 4} {
+		_, err := walkFS(context.Background(), fsys, ".", Options{MaxDepth: -1, Workers: workers, Archives: true, Strict: true})
+		assert.NotNil(t, err)
+
+		tr, err := walkFS(context.Background(), streamFS{fsys}, ".", Options{MaxDepth: -1, Workers: workers, Archives: true})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(5100), child(t, tr, "bundle.zip").Value.Size)
+	}
+
+	tr, err := walkFS(context.Background(), fsys, ".", Options{MaxDepth: 2, Workers: 1, Archives: true})
+	assert.Nil(t, err)
+
+	zipTree := child(t, tr, "bundle.zip")
+	assert.Equal(t, []string{"lib", "a.txt"}, names(zipTree))
+	assert.Equal(t, 0, len(child(t, zipTree, "lib").Children))
+	assert.Equal(t, 2, child(t, zipTree, "lib").Value.Count)
+
+	tr, err = walkFS(context.Background(), fsys, ".", Options{MaxDepth: 1, Workers: 1, Archives: true})
+	assert.Nil(t, err)
+
+	dist := child(t, tr, "dist")
+	assert.Equal(t, 0, len(dist.Children))
+	assert.Equal(t, 3+1, dist.Value.Count)
+	assert.Equal(t, int64(5100+10), dist.Value.Size)
+	assert.Equal(t, int64(5000), dist.Value.Extensions[".bin"].Size)
+
+	tr, err = walkFS(context.Background(), fsys, ".", Options{MaxDepth: -1, Workers: 1})
+	assert.Nil(t, err)
+	assert.False(t, child(t, tr, "bundle.zip").Value.IsDir)
+	assert.Equal(t, 3, tr.Value.Count)
+}
+
+func TestWalkFollowedArchives(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links require privileges on windows")
+	}
+	zipData := createZip(t, map[string]int{"a.txt": 100})
+	dir := t.TempDir()
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "real"), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "real", "bundle.zip"), zipData, 0644))
+	assert.Nil(t, os.Symlink(filepath.Join(dir, "real"), filepath.Join(dir, "a-link")))
+	assert.Nil(t, os.Symlink(filepath.Join("real", "bundle.zip"), filepath.Join(dir, "bundle-link.zip")))
+
+	for _, workers := range []int{1, 4} {
+		tr, err := walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: workers, Archives: true, FollowLinks: true})
+		assert.Nil(t, err)
+
+		// Only expanded at the path without links, and charged once.
+		assert.Equal(t, tree.ArchiveZip, child(t, tr, "real", "bundle.zip").Value.Archive)
+		for _, path := range [][]string{{"a-link", "bundle.zip"}, {"bundle-link.zip"}} {
+			link := child(t, tr, path...).Value
+			assert.False(t, link.IsDir)
+			assert.Equal(t, int64(0), link.Size)
+			assert.Equal(t, int64(len(zipData)), link.Linked)
+		}
+		assert.Equal(t, int64(100), tr.Value.Size)
+	}
+}
+
+func TestWalkArchiveTimes(t *testing.T) {
+	modified := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	accessed := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	buf := bytes.Buffer{}
+	w := tar.NewWriter(&buf)
+	assert.Nil(t, w.WriteHeader(&tar.Header{Name: "a.txt", Mode: 0644, Typeflag: tar.TypeReg, ModTime: modified, AccessTime: accessed, Format: tar.FormatPAX}))
+	assert.Nil(t, w.Close())
+	fsys := fstest.MapFS{"a.tar": &fstest.MapFile{Data: buf.Bytes()}}
+
+	for _, test := range []struct {
+		kind string
+		time time.Time
+	}{
+		{tree.TimeModified, modified},
+		{tree.TimeAccessed, accessed},
+		{tree.TimeChanged, modified},
+		{tree.TimeBirth, modified},
+	} {
+		tr, err := walkFS(context.Background(), fsys, ".", Options{MaxDepth: -1, Workers: 1, Archives: true, Time: test.kind})
+		assert.Nil(t, err)
+		assert.True(t, test.time.Equal(child(t, tr, "a.tar", "a.txt").Value.Time), test.kind)
+	}
+}
@@ -0,0 +1,171 @@
+package filesys
+
+import (
+	"io/fs"
+	"sync/atomic"
+	"time"
+
+	"github.com/mlange-42/dirstat/tree"
+)
+
+// Baseline is a previous analysis of the same directory, for incremental rescans.
+//
+// The listing of a directory is reused if the directory's modification time did not change
+// since the baseline, and if the baseline contains all its entries, i.e. it was not cropped or filtered.
+// Files in reused listings are not stat'ed again, except for files with multiple hard links,
+// and all files when following symbolic links. Other directories are read again,
+// while their sub-directories may still be reused.
+type Baseline struct {
+	tree      *tree.FileTree
+	reused    atomic.Int64
+	rescanned atomic.Int64
+}
+
+// NewBaseline creates a baseline from the tree of a previous analysis
+func NewBaseline(t *tree.FileTree) *Baseline {
+	return &Baseline{tree: t}
+}
+
+// Reused returns the number of directories with a listing reused from the baseline
+func (b *Baseline) Reused() int {
+	return int(b.reused.Load())
+}
+
+// Rescanned returns the number of directories that were read again
+func (b *Baseline) Rescanned() int {
+	return int(b.rescanned.Load())
+}
+
+// baselineDir is a directory of the walk, with its counterpart in the baseline
+type baselineDir struct {
+	base     *Baseline
+	node     *tree.FileTree            // The directory in the baseline, or nil if it is not contained
+	reuse    bool                      // Whether the listing from the baseline can be reused
+	children map[string]*tree.FileTree // Sub-directories in the baseline by name. Set when the directory is read
+}
+
+// root returns the baseline directory for the root of the walk, with timeKind the kind of file timestamps.
+// Nothing is reused if the baseline has another kind of timestamps.
+// Returns nil if b is nil, i.e. if no baseline is used.
+func (b *Baseline) root(d fs.DirEntry, timeKind string) *baselineDir {
+	if b == nil {
+		return nil
+	}
+	if kind, err := normalizeTimeKind(b.tree.Value.TimeKind); err != nil || kind != timeKind {
+		return &baselineDir{base: b}
+	}
+	return b.dir(b.tree, d)
+}
+
+// dir returns the baseline directory for d, with node being its counterpart in the baseline
+func (b *Baseline) dir(node *tree.FileTree, d fs.DirEntry) *baselineDir {
+	bd := &baselineDir{base: b, node: node}
+	if node == nil || node.Value.DirTime == nil || !completeListing(node) {
+		return bd
+	}
+	info, err := d.Info()
+	bd.reuse = err == nil && info.ModTime().Equal(*node.Value.DirTime)
+	return bd
+}
+
+// completeListing checks whether a directory in the baseline contains all of its entries,
+// and all entries can be reused.
+// Files and directories folded into the directory, by cropping or at the maximum depth of the walk, are detected by their counts.
+func completeListing(t *tree.FileTree) bool {
+	v := t.Value
+	if v.Incomplete || len(v.Errors) > 0 || len(v.Unscanned) > 0 || len(v.Archive) > 0 {
+		return false
+	}
+	count, dirs := 0, 1
+	for _, c := range t.Children {
+		if len(c.Value.Archive) > 0 || len(c.Value.Unscanned) > 0 || c.Value.Folded > 0 || (c.Value.IsDir && len(c.Value.Link) > 0) {
+			return false
+		}
+		count += c.Value.Count
+		dirs += c.Value.Dirs
+	}
+	return count == v.Count && dirs == v.Dirs
+}
+
+// enter returns the baseline directory for sub-directory d.
+// Returns nil if bd is nil, i.e. if no baseline is used.
+func (bd *baselineDir) enter(d fs.DirEntry) *baselineDir {
+	if bd == nil {
+		return nil
+	}
+	return bd.base.dir(bd.children[d.Name()], d)
+}
+
+// readDir reads the directory dirname, or reuses its listing from the baseline.
+// With follow, files are stat'ed again, except for links, which are resolved from their names.
+func (bd *baselineDir) readDir(fsys fileSystem, dirname string, follow bool) ([]fs.DirEntry, error) {
+	if bd == nil {
+		return fsys.ReadDir(dirname)
+	}
+	if bd.node != nil {
+		bd.children = map[string]*tree.FileTree{}
+		for _, c := range bd.node.Children {
+			if c.Value.IsDir {
+				bd.children[c.Value.Name] = c
+			}
+		}
+	}
+	if !bd.reuse {
+		bd.base.rescanned.Add(1)
+		return fsys.ReadDir(dirname)
+	}
+
+	bd.base.reused.Add(1)
+	entries := make([]fs.DirEntry, len(bd.node.Children))
+	for i, c := range bd.node.Children {
+		if c.Value.IsDir || c.Value.HardLinked || (follow && len(c.Value.Link) == 0) {
+			entries[i] = &baselineEntry{fsys: fsys, path: fsys.Join(dirname, c.Value.Name), name: c.Value.Name, isDir: c.Value.IsDir}
+		} else {
+			entries[i] = &baselineFile{c.Value}
+		}
+	}
+	return entries, nil
+}
+
+// baselineFile is a file from a baseline, which is not stat'ed again.
+// It serves as its own file info, with the baseline's entry as Sys.
+type baselineFile struct {
+	e *tree.FileEntry
+}
+
+func (f *baselineFile) Name() string               { return f.e.Name }
+func (f *baselineFile) IsDir() bool                { return false }
+func (f *baselineFile) Type() fs.FileMode          { return f.Mode().Type() }
+func (f *baselineFile) Info() (fs.FileInfo, error) { return f, nil }
+func (f *baselineFile) Size() int64                { return f.e.Size }
+func (f *baselineFile) ModTime() time.Time         { return f.e.Time }
+func (f *baselineFile) Sys() any                   { return f.e }
+
+func (f *baselineFile) Mode() fs.FileMode {
+	if len(f.e.Link) > 0 {
+		return fs.ModeSymlink | 0777
+	}
+	return 0644
+}
+
+// baselineEntry is an entry from a baseline, which is stat'ed again.
+// Sub-directories are stat'ed for comparing their modification time.
+// Files with multiple hard links, and all files when following links, are stat'ed for charging them like in a full scan,
+// as they may have been changed through a link in another directory.
+type baselineEntry struct {
+	fsys  fileSystem
+	path  string
+	name  string
+	isDir bool
+}
+
+func (d *baselineEntry) Name() string               { return d.name }
+func (d *baselineEntry) IsDir() bool                { return d.isDir }
+func (d *baselineEntry) Info() (fs.FileInfo, error) { return d.fsys.Lstat(d.path) }
+
+func (d *baselineEntry) Type() fs.FileMode {
+	if d.isDir {
+		return fs.ModeDir
+	}
+	return 0
+}
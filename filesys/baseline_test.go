@@ -0,0 +1,171 @@
+package filesys
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/mlange-42/dirstat/tree"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkBaseline(t *testing.T) {
+	dir := createTestDir(t, 1, 2, 3)
+	old := time.Now().Add(-time.Hour)
+	for _, d := range []string{dir, filepath.Join(dir, "Dir-0"), filepath.Join(dir, "Dir-1")} {
+		assert.Nil(t, os.Chtimes(d, old, old))
+	}
+
+	for _, workers := range []int{1, 4} {
+		tr, err := walk(context.Background(), dir, []string{}, -1, workers)
+		assert.Nil(t, err)
+
+		data, err := json.Marshal(tr)
+		assert.Nil(t, err)
+		snapshot, err := tree.Deserialize(data)
+		assert.Nil(t, err)
+
+		baseline := NewBaseline(snapshot)
+		tr, err = walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: workers, Baseline: baseline})
+		assert.Nil(t, err)
+		assert.Equal(t, 3, baseline.Reused())
+		assert.Equal(t, 0, baseline.Rescanned())
+		assert.Equal(t, 3*3, tr.Value.Count)
+		assert.Equal(t, int64(3*30), tr.Value.Size)
+	}
+
+	tr, err := walk(context.Background(), dir, []string{}, -1, 1)
+	assert.Nil(t, err)
+
+	// Changes the directory's modification time.
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "Dir-1", "new.txt"), make([]byte, 5), 0644))
+	// Does not change the directory's modification time, so it is not detected.
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "Dir-0", "file-2.txt"), make([]byte, 100), 0644))
+
+	baseline := NewBaseline(tr)
+	tr, err = walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: 1, Baseline: baseline})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, baseline.Reused())
+	assert.Equal(t, 1, baseline.Rescanned())
+	assert.Equal(t, int64(30), child(t, tr, "Dir-0").Value.Size)
+	assert.Equal(t, int64(35), child(t, tr, "Dir-1").Value.Size)
+	assert.Equal(t, 3*3+1, tr.Value.Count)
+
+	cropped, err := walk(context.Background(), dir, []string{}, 0, 1)
+	assert.Nil(t, err)
+
+	baseline = NewBaseline(cropped)
+	tr, err = walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: 1, Baseline: baseline})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, baseline.Reused())
+	assert.Equal(t, 3, baseline.Rescanned())
+	assert.Equal(t, int64(3*30+80+5), tr.Value.Size)
+}
+
+func TestWalkBaselineBottom(t *testing.T) {
+	dir := createTestDir(t, 1, 2, 3)
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "Dir-0", "empty"), 0755))
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "Dir-1", "sub", "empty"), 0755))
+	old := time.Now().Add(-time.Hour)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.IsDir() {
+			err = os.Chtimes(path, old, old)
+		}
+		return err
+	})
+	assert.Nil(t, err)
+
+	tr, err := walk(context.Background(), dir, []string{}, -1, 1)
+	assert.Nil(t, err)
+
+	// Empty directories at the bottom of the baseline are reused.
+	baseline := NewBaseline(tr)
+	tr, err = walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: 1, Baseline: baseline})
+	assert.Nil(t, err)
+	assert.Equal(t, 6, baseline.Reused())
+	assert.Equal(t, 0, baseline.Rescanned())
+	assert.Equal(t, 6, tr.Value.Dirs)
+	assert.Equal(t, 2, tr.Value.Empty)
+
+	// Directories with folded sub-directories are read again, as well as their sub-directories.
+	cropped, err := walk(context.Background(), dir, []string{}, 2, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(child(t, cropped, "Dir-1", "sub").Children))
+
+	baseline = NewBaseline(cropped)
+	tr, err = walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: 1, Baseline: baseline})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, baseline.Reused())
+	assert.Equal(t, 2, baseline.Rescanned())
+	assert.Equal(t, 6, tr.Value.Dirs)
+	assert.Equal(t, 2, tr.Value.Empty)
+	assert.Equal(t, 1, len(child(t, tr, "Dir-1", "sub").Children))
+}
+
+func TestWalkBaselineHardLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hard links are not detected on windows")
+	}
+	dir := createTestDir(t, 1, 2, 3)
+	assert.Nil(t, os.Link(filepath.Join(dir, "Dir-1", "file-2.txt"), filepath.Join(dir, "Dir-0", "link.txt")))
+	old := time.Now().Add(-time.Hour)
+	for _, d := range []string{dir, filepath.Join(dir, "Dir-0"), filepath.Join(dir, "Dir-1")} {
+		assert.Nil(t, os.Chtimes(d, old, old))
+	}
+
+	tr, err := walk(context.Background(), dir, []string{}, -1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3*30), tr.Value.Size)
+	assert.Equal(t, int64(20), tr.Value.Linked)
+
+	// Dir-1 is read again, while the link in Dir-0 is reused.
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "Dir-1", "new.txt"), make([]byte, 5), 0644))
+
+	for _, workers := range []int{1, 4} {
+		baseline := NewBaseline(tr)
+		tr, err := walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: workers, Baseline: baseline})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, baseline.Reused())
+		assert.Equal(t, 1, baseline.Rescanned())
+		assert.Equal(t, int64(3*30+5), tr.Value.Size)
+		assert.Equal(t, int64(20), tr.Value.Linked)
+		assert.Equal(t, int64(20), child(t, tr, "Dir-0", "link.txt").Value.Size)
+		assert.Equal(t, int64(20), child(t, tr, "Dir-1", "file-2.txt").Value.Linked)
+	}
+}
+
+func TestWalkBaselineFollowLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links require privileges on windows")
+	}
+	dir := createTestDir(t, 1, 2, 3)
+	assert.Nil(t, os.Symlink(filepath.Join("..", "Dir-1", "file-2.txt"), filepath.Join(dir, "Dir-0", "link.txt")))
+	old := time.Now().Add(-time.Hour)
+	for _, d := range []string{dir, filepath.Join(dir, "Dir-0"), filepath.Join(dir, "Dir-1")} {
+		assert.Nil(t, os.Chtimes(d, old, old))
+	}
+
+	tr, err := walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: 1, FollowLinks: true})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3*30), tr.Value.Size)
+	assert.Equal(t, int64(20), tr.Value.Linked)
+
+	// Dir-0 is read again, while the link's target in Dir-1 is reused.
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "Dir-0", "new.txt"), make([]byte, 5), 0644))
+
+	for _, workers := range []int{1, 4} {
+		baseline := NewBaseline(tr)
+		tr, err := walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: workers, FollowLinks: true, Baseline: baseline})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, baseline.Reused())
+		assert.Equal(t, 1, baseline.Rescanned())
+		assert.Equal(t, int64(3*30+5), tr.Value.Size)
+		assert.Equal(t, int64(20), tr.Value.Linked)
+		assert.Equal(t, int64(20), child(t, tr, "Dir-0", "link.txt").Value.Linked)
+		assert.Equal(t, int64(20), child(t, tr, "Dir-1", "file-2.txt").Value.Size)
+	}
+}
@@ -0,0 +1,192 @@
+package filesys
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CategoryOther is the category of files that match no other category
+const CategoryOther = "other"
+
+// mimeExecutable is the MIME type assigned to native executables and libraries,
+// which are not detected by http.DetectContentType
+const mimeExecutable = "application/x-executable"
+
+// CategoryDef defines a category of files by extensions and MIME types.
+// MIME types ending with a slash, like "image/", match all types with that prefix.
+type CategoryDef struct {
+	Extensions []string `json:"extensions"`
+	MIME       []string `json:"mime"`
+}
+
+// DefaultCategories returns the default definitions of file categories
+func DefaultCategories() map[string]CategoryDef {
+	return map[string]CategoryDef{
+		"images": {
+			Extensions: []string{".png", ".jpg", ".jpeg", ".gif", ".bmp", ".svg", ".webp", ".tif", ".tiff", ".ico", ".heic", ".psd", ".raw"},
+			MIME:       []string{"image/"},
+		},
+		"video": {
+			Extensions: []string{".mp4", ".mkv", ".avi", ".mov", ".wmv", ".webm", ".flv", ".m4v", ".mpg", ".mpeg"},
+			MIME:       []string{"video/"},
+		},
+		"audio": {
+			Extensions: []string{".mp3", ".wav", ".flac", ".ogg", ".aac", ".m4a", ".wma", ".opus", ".mid"},
+			MIME:       []string{"audio/"},
+		},
+		"archives": {
+			Extensions: []string{".zip", ".jar", ".war", ".tar", ".gz", ".tgz", ".bz2", ".xz", ".zst", ".7z", ".rar", ".iso"},
+			MIME:       []string{"application/x-gzip", "application/x-rar-compressed"},
+		},
+		"documents": {
+			Extensions: []string{".pdf", ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx", ".odt", ".ods", ".odp", ".rtf", ".epub", ".txt", ".md", ".rst", ".tex", ".csv"},
+			MIME:       []string{"application/pdf", "application/postscript"},
+		},
+		"source": {
+			Extensions: []string{
+				".go", ".c", ".h", ".cpp", ".hpp", ".cc", ".cs", ".rs", ".py", ".js", ".ts", ".jsx", ".tsx", ".java", ".kt", ".scala",
+				".rb", ".php", ".swift", ".lua", ".pl", ".r", ".sh", ".bat", ".ps1", ".sql", ".html", ".css", ".scss",
+				".json", ".yaml", ".yml", ".toml", ".xml", ".mod", ".sum",
+			},
+		},
+		"binaries": {
+			Extensions: []string{".exe", ".dll", ".so", ".dylib", ".a", ".o", ".lib", ".bin", ".class", ".pyc", ".wasm"},
+			MIME:       []string{mimeExecutable, "application/wasm"},
+		},
+		"logs": {
+			Extensions: []string{".log"},
+		},
+	}
+}
+
+// Categories assigns files to categories, by MIME type and extension.
+// MIME types take precedence, as they are detected from the content.
+type Categories struct {
+	extensions map[string]string
+	mime       map[string]string
+}
+
+// NewCategories creates categories from definitions.
+// For extensions and MIME types in multiple categories, later definitions take precedence.
+func NewCategories(defs ...map[string]CategoryDef) *Categories {
+	c := Categories{extensions: map[string]string{}, mime: map[string]string{}}
+	for _, d := range defs {
+		names := make([]string, 0, len(d))
+		for name := range d {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, ext := range d[name].Extensions {
+				c.extensions[strings.ToLower(ext)] = name
+			}
+			for _, mime := range d[name].MIME {
+				c.mime[strings.ToLower(mime)] = name
+			}
+		}
+	}
+	return &c
+}
+
+// category returns the category of a file, by its MIME type (if known) and its extension.
+// Returns an empty string if c is nil, i.e. if files are not categorized.
+func (c *Categories) category(name string, mime string) string {
+	if c == nil {
+		return ""
+	}
+	if len(mime) > 0 {
+		if cat, ok := c.mime[mime]; ok {
+			return cat
+		}
+		if i := strings.IndexByte(mime, '/'); i >= 0 {
+			if cat, ok := c.mime[mime[:i+1]]; ok {
+				return cat
+			}
+		}
+	}
+	if cat, ok := c.extensions[strings.ToLower(filepath.Ext(name))]; ok {
+		return cat
+	}
+	return CategoryOther
+}
+
+// sniffedEntry is a file entry with the MIME type detected from its content
+type sniffedEntry struct {
+	fs.DirEntry
+	mime string
+}
+
+// sniffEntries wraps regular files in entries by entries with their MIME type
+func sniffEntries(fsys fileSystem, dirname string, entries []fs.DirEntry) {
+	for i, e := range entries {
+		switch e.(type) {
+		case *linkDirEntry, *baselineFile:
+			// Keep links identifiable, and don't read reused files.
+			continue
+		}
+		if e.Type().IsRegular() {
+			entries[i] = &sniffedEntry{e, sniffMIME(fsys, fsys.Join(dirname, e.Name()))}
+		}
+	}
+}
+
+// mimeType returns the MIME type of an entry, or an empty string if it was not detected
+func mimeType(d fs.DirEntry) string {
+	if a, ok := d.(*archivedEntry); ok {
+		d = a.DirEntry
+	}
+	if s, ok := d.(*sniffedEntry); ok {
+		return s.mime
+	}
+	return ""
+}
+
+// sniffMIME detects the MIME type of a file from its first 512 bytes, without parameters.
+// Returns an empty string if the file can't be read, or is empty.
+func sniffMIME(fsys fileSystem, path string) string {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, _ := io.ReadFull(f, buf)
+	if n == 0 {
+		return ""
+	}
+	buf = buf[:n]
+	if isExecutable(buf) {
+		return mimeExecutable
+	}
+	mime := http.DetectContentType(buf)
+	if i := strings.IndexByte(mime, ';'); i >= 0 {
+		mime = mime[:i]
+	}
+	return mime
+}
+
+// executableMagic are the leading bytes of ELF, PE and Mach-O files
+var executableMagic = [][]byte{
+	[]byte("\x7fELF"),
+	[]byte("MZ"),
+	{0xfe, 0xed, 0xfa, 0xce},
+	{0xfe, 0xed, 0xfa, 0xcf},
+	{0xce, 0xfa, 0xed, 0xfe},
+	{0xcf, 0xfa, 0xed, 0xfe},
+}
+
+// isExecutable checks whether data starts like a native executable or library
+func isExecutable(data []byte) bool {
+	for _, m := range executableMagic {
+		if bytes.HasPrefix(data, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package filesys
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategories(t *testing.T) {
+	cats := NewCategories(DefaultCategories(), map[string]CategoryDef{
+		"models": {Extensions: []string{".ONNX", ".bin"}, MIME: []string{"application/x-hdf"}},
+	})
+
+	assert.Equal(t, "images", cats.category("photo.JPG", ""))
+	assert.Equal(t, "models", cats.category("net.onnx", ""))
+	assert.Equal(t, "models", cats.category("weights.bin", ""))
+	assert.Equal(t, "models", cats.category("data", "application/x-hdf"))
+	assert.Equal(t, "images", cats.category("data.txt", "image/png"))
+	assert.Equal(t, "documents", cats.category("data.txt", "text/plain"))
+	assert.Equal(t, CategoryOther, cats.category("data", ""))
+
+	var none *Categories
+	assert.Equal(t, "", none.category("photo.jpg", ""))
+}
+
+func TestSniffMIME(t *testing.T) {
+	fsys := ioFS{fstest.MapFS{
+		"app":      &fstest.MapFile{Data: []byte("\x7fELF\x02\x01\x01\x00")},
+		"pic.dat":  &fstest.MapFile{Data: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")},
+		"notes":    &fstest.MapFile{Data: []byte("plain text")},
+		"empty.go": &fstest.MapFile{},
+	}}
+
+	assert.Equal(t, mimeExecutable, sniffMIME(fsys, "app"))
+	assert.Equal(t, "image/png", sniffMIME(fsys, "pic.dat"))
+	assert.Equal(t, "text/plain", sniffMIME(fsys, "notes"))
+	assert.Equal(t, "", sniffMIME(fsys, "empty.go"))
+	assert.Equal(t, "", sniffMIME(fsys, "missing"))
+}
+
+func TestWalkCategories(t *testing.T) {
+	fsys := fstest.MapFS{
+		"bin/app":      &fstest.MapFile{Data: []byte("\x7fELF\x02\x01\x01\x00")},
+		"img/pic.dat":  &fstest.MapFile{Data: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")},
+		"img/logo.png": &fstest.MapFile{Data: make([]byte, 100)},
+		"src/main.go":  &fstest.MapFile{Data: []byte("package main")},
+	}
+	cats := NewCategories(DefaultCategories())
+
+	for _, workers := range []int{1, 4} {
+		tr, err := walkFS(context.Background(), fsys, ".", Options{MaxDepth: -1, Workers: workers, Categories: cats})
+		assert.Nil(t, err)
+		assert.Equal(t, "other", child(t, tr, "bin", "app").Value.Category)
+		assert.Equal(t, "other", child(t, tr, "img", "pic.dat").Value.Category)
+		assert.Equal(t, 1, child(t, tr, "img").Value.Categories["images"].Count)
+
+		tr, err = walkFS(context.Background(), fsys, ".", Options{MaxDepth: -1, Workers: workers, Categories: cats, Sniff: true})
+		assert.Nil(t, err)
+		assert.Equal(t, "binaries", child(t, tr, "bin", "app").Value.Category)
+		assert.Equal(t, "images", child(t, tr, "img", "pic.dat").Value.Category)
+		assert.Equal(t, "source", child(t, tr, "src", "main.go").Value.Category)
+		assert.Equal(t, 2, child(t, tr, "img").Value.Categories["images"].Count)
+		assert.Equal(t, int64(116), child(t, tr, "img").Value.Categories["images"].Size)
+	}
+
+	tr, err := walkFS(context.Background(), fsys, ".", Options{MaxDepth: -1, Workers: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, "", child(t, tr, "bin", "app").Value.Category)
+	assert.Equal(t, 0, len(child(t, tr, "img").Value.Categories))
+}
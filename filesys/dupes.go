@@ -0,0 +1,150 @@
+package filesys
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"io/fs"
+	"sort"
+
+	"github.com/mlange-42/dirstat/tree"
+)
+
+// partialHashSize is the number of leading bytes hashed to rule out most candidates
+// before reading files completely
+const partialHashSize = 4096
+
+// DuplicateSet is a set of files with identical content
+type DuplicateSet struct {
+	Size   int64    `json:"size"`   // Size of each file
+	Wasted int64    `json:"wasted"` // Bytes used by all but one of the files
+	Paths  []string `json:"paths"`
+}
+
+// FindDuplicates finds files with identical content in a tree from Walk of directory dir.
+// The tree must have unlimited depth, as only individually listed files are compared.
+//
+// Files smaller than minSize, empty files, symbolic links, further hard links to the same file
+// and files inside archives are not considered. Files that can't be read are skipped.
+// Sets are sorted by wasted bytes, in descending order.
+func FindDuplicates(ctx context.Context, dir string, t *tree.FileTree, minSize int64) ([]DuplicateSet, error) {
+	return findDuplicates(ctx, osFS{}, dir, t, minSize)
+}
+
+// FindDuplicatesFS finds files with identical content in a tree from WalkFS of root in fsys.
+// See FindDuplicates for details.
+func FindDuplicatesFS(ctx context.Context, fsys fs.FS, root string, t *tree.FileTree, minSize int64) ([]DuplicateSet, error) {
+	return findDuplicates(ctx, ioFS{fsys}, root, t, minSize)
+}
+
+// findDuplicates groups files by size, and confirms duplicates by partial and full content hashes
+func findDuplicates(ctx context.Context, fsys fileSystem, root string, t *tree.FileTree, minSize int64) ([]DuplicateSet, error) {
+	bySize := map[int64][]string{}
+	collectFiles(fsys, root, t, minSize, bySize)
+
+	sets := []DuplicateSet{}
+	for size, paths := range bySize {
+		if len(paths) < 2 {
+			continue
+		}
+		for _, group := range groupByHash(ctx, fsys, paths, partialHashSize) {
+			if size > partialHashSize {
+				// Only the leading bytes were compared so far.
+				for _, g := range groupByHash(ctx, fsys, group, -1) {
+					sets = append(sets, newDuplicateSet(size, g))
+				}
+			} else {
+				sets = append(sets, newDuplicateSet(size, group))
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			sortDuplicates(sets)
+			return sets, err
+		}
+	}
+	sortDuplicates(sets)
+	return sets, nil
+}
+
+// collectFiles collects the paths of candidate files in t, by size
+func collectFiles(fsys fileSystem, root string, t *tree.FileTree, minSize int64, bySize map[int64][]string) {
+	t.Walk(func(n tree.Node[*tree.FileEntry]) tree.WalkAction {
+		v := n.Tree.Value
+		if v.IsDir {
+			if len(v.Archive) > 0 {
+				// Virtual directory, files can't be read.
+				return tree.SkipChildren
+			}
+			return tree.Continue
+		}
+		if v.Size > 0 && v.Size >= minSize && len(v.Link) == 0 && v.Linked == 0 {
+			path := root
+			if n.Depth() > 0 {
+				for _, a := range n.Ancestors[1:] {
+					path = fsys.Join(path, a.Value.Name)
+				}
+				path = fsys.Join(path, v.Name)
+			}
+			bySize[v.Size] = append(bySize[v.Size], path)
+		}
+		return tree.Continue
+	}, nil)
+}
+
+// groupByHash groups files by the hash of their first limit bytes, or of their full content for limit < 0.
+// Only groups with at least two files are returned. Files that can't be read are skipped.
+func groupByHash(ctx context.Context, fsys fileSystem, paths []string, limit int64) [][]string {
+	byHash := map[[sha256.Size]byte][]string{}
+	for _, p := range paths {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if h, err := hashFile(fsys, p, limit); err == nil {
+			byHash[h] = append(byHash[h], p)
+		}
+	}
+	groups := [][]string{}
+	for _, g := range byHash {
+		if len(g) > 1 {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// hashFile hashes the first limit bytes of a file, or its full content for limit < 0
+func hashFile(fsys fileSystem, path string, limit int64) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	f, err := fsys.Open(path)
+	if err != nil {
+		return sum, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if limit >= 0 {
+		r = io.LimitReader(f, limit)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return sum, err
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// newDuplicateSet creates a set of duplicates with sorted paths
+func newDuplicateSet(size int64, paths []string) DuplicateSet {
+	sort.Strings(paths)
+	return DuplicateSet{Size: size, Wasted: size * int64(len(paths)-1), Paths: paths}
+}
+
+// sortDuplicates sorts sets by wasted bytes in descending order, and by path
+func sortDuplicates(sets []DuplicateSet) {
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].Wasted != sets[j].Wasted {
+			return sets[i].Wasted > sets[j].Wasted
+		}
+		return sets[i].Paths[0] < sets[j].Paths[0]
+	})
+}
@@ -0,0 +1,47 @@
+package filesys
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	large := bytes.Repeat([]byte("data"), 2000)
+	// Same size and leading bytes as large, but different at the end.
+	tail := append(bytes.Repeat([]byte("data"), 1999), []byte("tail")...)
+	fsys := fstest.MapFS{
+		"a/data.csv":     &fstest.MapFile{Data: large},
+		"b/copy.csv":     &fstest.MapFile{Data: large},
+		"b/sub/data.csv": &fstest.MapFile{Data: large},
+		"c/tail.csv":     &fstest.MapFile{Data: tail},
+		"a/small.txt":    &fstest.MapFile{Data: []byte("small")},
+		"c/small.txt":    &fstest.MapFile{Data: []byte("small")},
+		"c/other.txt":    &fstest.MapFile{Data: []byte("other")},
+		"a/empty":        &fstest.MapFile{},
+		"b/empty":        &fstest.MapFile{},
+	}
+
+	tr, err := walkFS(context.Background(), fsys, ".", Options{MaxDepth: -1, Workers: 1})
+	assert.Nil(t, err)
+
+	sets, err := FindDuplicatesFS(context.Background(), fsys, ".", tr, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []DuplicateSet{
+		{Size: 8000, Wasted: 16000, Paths: []string{"a/data.csv", "b/copy.csv", "b/sub/data.csv"}},
+		{Size: 5, Wasted: 5, Paths: []string{"a/small.txt", "c/small.txt"}},
+	}, sets)
+
+	sets, err = FindDuplicatesFS(context.Background(), fsys, ".", tr, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(sets))
+	assert.Equal(t, int64(8000), sets[0].Size)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = FindDuplicatesFS(ctx, fsys, ".", tr, 0)
+	assert.Equal(t, context.Canceled, err)
+}
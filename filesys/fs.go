@@ -0,0 +1,80 @@
+package filesys
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// ReadLinkFS is a file system with support for symbolic links.
+//
+// If the file system passed to WalkFS implements it, links are listed with their target,
+// and are not followed unless requested.
+type ReadLinkFS interface {
+	fs.FS
+	// ReadLink returns the destination of the named symbolic link
+	ReadLink(name string) (string, error)
+	// Lstat returns information about the named file, without following symbolic links
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// fileSystem is the file system read by the walker
+type fileSystem interface {
+	Stat(name string) (fs.FileInfo, error)
+	Lstat(name string) (fs.FileInfo, error)
+	ReadLink(name string) (string, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	ReadFile(name string) ([]byte, error)
+	Open(name string) (fs.File, error)
+	Join(elem ...string) string
+}
+
+// osFS is the file system of the operating system, with OS-specific paths
+type osFS struct{}
+
+func (osFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (osFS) Lstat(name string) (fs.FileInfo, error)     { return os.Lstat(name) }
+func (osFS) ReadLink(name string) (string, error)       { return os.Readlink(name) }
+func (osFS) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+func (osFS) Open(name string) (fs.File, error)          { return os.Open(name) }
+func (osFS) Join(elem ...string) string                 { return filepath.Join(elem...) }
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) { return readDirPartial(name) }
+
+// readDirPartial reads the directory named by dirname.
+// On error, it returns the entries read before the error.
+func readDirPartial(dirname string) ([]fs.DirEntry, error) {
+	f, err := os.Open(dirname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.ReadDir(-1)
+}
+
+// ioFS wraps an fs.FS, with slash-separated paths
+type ioFS struct {
+	fsys fs.FS
+}
+
+func (f ioFS) Stat(name string) (fs.FileInfo, error)      { return fs.Stat(f.fsys, name) }
+func (f ioFS) ReadFile(name string) ([]byte, error)       { return fs.ReadFile(f.fsys, name) }
+func (f ioFS) Open(name string) (fs.File, error)          { return f.fsys.Open(name) }
+func (f ioFS) ReadDir(name string) ([]fs.DirEntry, error) { return fs.ReadDir(f.fsys, name) }
+func (f ioFS) Join(elem ...string) string                 { return path.Join(elem...) }
+
+// Lstat uses fs.Stat if the file system does not support symbolic links
+func (f ioFS) Lstat(name string) (fs.FileInfo, error) {
+	if l, ok := f.fsys.(ReadLinkFS); ok {
+		return l.Lstat(name)
+	}
+	return fs.Stat(f.fsys, name)
+}
+
+// ReadLink fails if the file system does not support symbolic links
+func (f ioFS) ReadLink(name string) (string, error) {
+	if l, ok := f.fsys.(ReadLinkFS); ok {
+		return l.ReadLink(name)
+	}
+	return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+}
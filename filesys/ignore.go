@@ -0,0 +1,131 @@
+package filesys
+
+import (
+	"io/fs"
+	"strings"
+)
+
+// IgnoreMode determines how ignore files (.gitignore and .dirstatignore) are used
+type IgnoreMode int
+
+const (
+	// IgnoreOff does not use ignore files
+	IgnoreOff IgnoreMode = iota
+	// IgnoreRespect skips ignored files and directories
+	IgnoreRespect
+	// IgnoreInvert includes only ignored files and directories
+	IgnoreInvert
+)
+
+// ignoreFiles are the names of files with ignore patterns.
+// Patterns in later files take precedence.
+var ignoreFiles = []string{".gitignore", ".dirstatignore"}
+
+// ignoreRules are the patterns from the ignore files of a directory,
+// linked to the rules of its parent directories
+type ignoreRules struct {
+	mode     IgnoreMode
+	parent   *ignoreRules
+	dir      string
+	patterns patterns
+	all      bool // Whether the directory itself is ignored, and with it everything inside
+}
+
+// newIgnoreRules creates the rules for the root directory.
+// Returns nil if ignore files are not used.
+func newIgnoreRules(mode IgnoreMode, root string) *ignoreRules {
+	if mode == IgnoreOff {
+		return nil
+	}
+	return &ignoreRules{mode: mode, dir: root}
+}
+
+// ignored checks whether a path is ignored.
+// Patterns of deeper directories take precedence.
+func (r *ignoreRules) ignored(path string, isDir bool) bool {
+	for ; r != nil; r = r.parent {
+		if r.all {
+			return true
+		}
+		if len(r.patterns) == 0 {
+			continue
+		}
+		if ignored, ok := r.patterns.decide(relativePath(r.dir, path), isDir); ok {
+			return ignored
+		}
+	}
+	return false
+}
+
+// enter returns the rules for descending into sub-directory path.
+// Only required for IgnoreInvert, as ignored directories are skipped otherwise.
+func (r *ignoreRules) enter(path string) *ignoreRules {
+	if r == nil || r.all || r.mode != IgnoreInvert || !r.ignored(path, true) {
+		return r
+	}
+	return &ignoreRules{mode: r.mode, parent: r, dir: path, all: true}
+}
+
+// filter loads the ignore files of directory dir, found in entries,
+// and filters the entries according to the mode.
+// Returns the filtered entries and the rules for sub-directories.
+//
+// The .git directory is skipped, as it is neither tracked nor ignored.
+func (r *ignoreRules) filter(fsys fileSystem, dir string, entries []fs.DirEntry) ([]fs.DirEntry, *ignoreRules) {
+	if r == nil || r.all {
+		return entries, r
+	}
+	r = r.load(fsys, dir, entries)
+
+	result := entries[:0]
+	for _, e := range entries {
+		if e.IsDir() && e.Name() == ".git" {
+			continue
+		}
+		ignored := r.ignored(fsys.Join(dir, e.Name()), e.IsDir())
+		if (r.mode == IgnoreRespect && !ignored) || (r.mode == IgnoreInvert && (ignored || e.IsDir())) {
+			result = append(result, e)
+		}
+	}
+	return result, r
+}
+
+// load reads the ignore files of directory dir, if present in entries.
+// Returns r if there are no ignore files.
+func (r *ignoreRules) load(fsys fileSystem, dir string, entries []fs.DirEntry) *ignoreRules {
+	var ps patterns
+	for _, name := range ignoreFiles {
+		for _, e := range entries {
+			if e.Name() == name && e.Type().IsRegular() {
+				ps = append(ps, readIgnoreFile(fsys, fsys.Join(dir, name))...)
+			}
+		}
+	}
+	if len(ps) == 0 {
+		return r
+	}
+	return &ignoreRules{mode: r.mode, parent: r, dir: dir, patterns: ps}
+}
+
+// readIgnoreFile reads the patterns of an ignore file.
+// Skips blank lines, comments and invalid patterns, like git does.
+func readIgnoreFile(fsys fileSystem, path string) patterns {
+	data, err := fsys.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var ps patterns
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r ")
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if p, err := compilePattern(line); err == nil {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
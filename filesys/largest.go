@@ -0,0 +1,58 @@
+package filesys
+
+import (
+	"container/heap"
+	"sort"
+
+	"github.com/mlange-42/dirstat/tree"
+)
+
+// largestFiles collects the n largest files, by apparent size.
+// It is a min-heap, with the smallest of the collected files first.
+// Of files with equal size, those with the lower path are preferred, independent of the order of the walk.
+type largestFiles struct {
+	n     int
+	files []tree.LargeFile
+}
+
+func (h *largestFiles) Len() int           { return len(h.files) }
+func (h *largestFiles) Less(i, j int) bool { return smallerFile(h.files[i], h.files[j]) }
+func (h *largestFiles) Swap(i, j int)      { h.files[i], h.files[j] = h.files[j], h.files[i] }
+func (h *largestFiles) Push(x any)         { h.files = append(h.files, x.(tree.LargeFile)) }
+func (h *largestFiles) Pop() any {
+	f := h.files[len(h.files)-1]
+	h.files = h.files[:len(h.files)-1]
+	return f
+}
+
+// add adds a file if it is among the n largest files so far
+func (h *largestFiles) add(f tree.LargeFile) {
+	if h.n <= 0 || f.Size <= 0 {
+		return
+	}
+	if len(h.files) < h.n {
+		heap.Push(h, f)
+	} else if smallerFile(h.files[0], f) {
+		h.files[0] = f
+		heap.Fix(h, 0)
+	}
+}
+
+// smallerFile checks whether file a is smaller than b, or has a higher path if both have the same size
+func smallerFile(a, b tree.LargeFile) bool {
+	if a.Size != b.Size {
+		return a.Size < b.Size
+	}
+	return a.Path > b.Path
+}
+
+// sorted returns the collected files, largest first.
+// Returns nil if no files were collected.
+func (h *largestFiles) sorted() []tree.LargeFile {
+	if len(h.files) == 0 {
+		return nil
+	}
+	files := append([]tree.LargeFile{}, h.files...)
+	sort.Slice(files, func(i, j int) bool { return smallerFile(files[j], files[i]) })
+	return files
+}
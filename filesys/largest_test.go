@@ -0,0 +1,48 @@
+package filesys
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mlange-42/dirstat/tree"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLargestFiles(t *testing.T) {
+	h := largestFiles{n: 3}
+	assert.Nil(t, h.sorted())
+
+	for i, size := range []int64{5, 1, 8, 3, 0, 9, 2} {
+		h.add(tree.LargeFile{Path: string(rune('a' + i)), Size: size})
+	}
+	assert.Equal(t, []tree.LargeFile{{Path: "f", Size: 9}, {Path: "c", Size: 8}, {Path: "a", Size: 5}}, h.sorted())
+
+	none := largestFiles{}
+	none.add(tree.LargeFile{Path: "a", Size: 5})
+	assert.Nil(t, none.sorted())
+}
+
+func TestWalkLargestFiles(t *testing.T) {
+	fsys := createTestFS(2, 2, 3)
+
+	for _, workers := range []int{1, 4} {
+		for _, depth := range []int{-1, 0} {
+			tr, err := walkFS(context.Background(), fsys, ".", Options{MaxDepth: depth, Workers: workers, TopFiles: 3})
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"Dir-0/Dir-0/file-2.txt", "Dir-0/Dir-1/file-2.txt", "Dir-0/file-2.txt"}, largestPaths(tr))
+			assert.Equal(t, int64(20), tr.Value.Largest[0].Size)
+		}
+	}
+
+	tr, err := walkFS(context.Background(), fsys, ".", Options{MaxDepth: -1, Workers: 1})
+	assert.Nil(t, err)
+	assert.Nil(t, tr.Value.Largest)
+}
+
+func largestPaths(tr *tree.FileTree) []string {
+	paths := []string{}
+	for _, f := range tr.Value.Largest {
+		paths = append(paths, f.Path)
+	}
+	return paths
+}
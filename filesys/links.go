@@ -0,0 +1,98 @@
+package filesys
+
+import (
+	"io/fs"
+	"os"
+)
+
+// linkDirEntry is a symbolic link, resolved to the info of its target
+type linkDirEntry struct {
+	statDirEntry
+	target string
+}
+
+// dirChain is a directory and its ancestors.
+// Used for detecting cycles when following symbolic links.
+type dirChain struct {
+	info   fs.FileInfo
+	parent *dirChain
+}
+
+// dirState is the state of a directory during a walk, inherited by its sub-directories
+type dirState struct {
+	links    *dirChain    // Ancestor directories, for detecting cycles. Nil if links are not followed
+	ignore   *ignoreRules // Rules from ignore files. Nil if ignore files are not used
+	baseline *baselineDir // Counterpart in the baseline. Nil if no baseline is used
+	sniff    bool         // Whether to detect MIME types of files from their content
+	archives bool         // Whether to read archives in workers of the parallel walk. Not inside followed links, where they are not expanded
+	birth    bool         // Whether to read birth times of files in workers of the parallel walk
+}
+
+// enter returns the state for descending into sub-directory d at path
+func (s dirState) enter(path string, d fs.DirEntry) dirState {
+	if !d.IsDir() {
+		return s
+	}
+	_, followed := d.(*linkDirEntry)
+	return dirState{links: enterDir(s.links, d), ignore: s.ignore.enter(path), baseline: s.baseline.enter(d), sniff: s.sniff, archives: s.archives && !followed, birth: s.birth}
+}
+
+// enterDir returns the chain for descending into directory d.
+// Returns nil if chain is nil, i.e. when links are not followed.
+func enterDir(chain *dirChain, d fs.DirEntry) *dirChain {
+	if chain == nil {
+		return nil
+	}
+	info, err := d.Info()
+	if err != nil {
+		return chain
+	}
+	return &dirChain{info: info, parent: chain}
+}
+
+// contains checks whether the chain contains the same directory as info,
+// by device and inode
+func (c *dirChain) contains(info fs.FileInfo) bool {
+	for ; c != nil; c = c.parent {
+		if c.info != nil && os.SameFile(c.info, info) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveLinks replaces symbolic links in entries by entries for their targets.
+// Broken links and links to directories in chain, i.e. cycles, are kept as they are.
+func resolveLinks(fsys fileSystem, dirname string, entries []fs.DirEntry, chain *dirChain) {
+	for i, e := range entries {
+		if e.Type()&fs.ModeSymlink == 0 {
+			continue
+		}
+		path := fsys.Join(dirname, e.Name())
+		info, err := fsys.Stat(path)
+		if err != nil || (info.IsDir() && chain.contains(info)) {
+			continue
+		}
+		target, err := fsys.ReadLink(path)
+		if err != nil {
+			continue
+		}
+		entries[i] = &linkDirEntry{statDirEntry{info}, target}
+	}
+}
+
+// linkTarget returns the target of a symbolic link,
+// or an empty string if the entry is not a link
+func linkTarget(fsys fileSystem, path string, d fs.DirEntry) string {
+	if l, ok := d.(*linkDirEntry); ok {
+		return l.target
+	}
+	if d.Type()&fs.ModeSymlink == 0 {
+		return ""
+	}
+	target, err := fsys.ReadLink(path)
+	if err != nil {
+		return ""
+	}
+	return target
+}
@@ -0,0 +1,48 @@
+package filesys
+
+import (
+	"io/fs"
+	"os/user"
+	"strconv"
+
+	"github.com/mlange-42/dirstat/tree"
+)
+
+// ownerKey is the numeric user and group owning a file
+type ownerKey struct {
+	uid uint32
+	gid uint32
+}
+
+// ownerNames resolves the owners of files to names, as "user:group".
+// Names are cached, as lookups may be slow.
+type ownerNames map[ownerKey]string
+
+// name returns the owner of a file as "user:group".
+// Users and groups that can't be resolved are given by their numeric ID.
+// Returns an empty string if owners are not available.
+func (o ownerNames) name(info fs.FileInfo) string {
+	if e, ok := info.Sys().(*tree.FileEntry); ok {
+		// Reused from the baseline.
+		return e.Owner
+	}
+	key, ok := ownerID(info)
+	if !ok {
+		return ""
+	}
+	if name, ok := o[key]; ok {
+		return name
+	}
+
+	uid := strconv.FormatUint(uint64(key.uid), 10)
+	if u, err := user.LookupId(uid); err == nil {
+		uid = u.Username
+	}
+	gid := strconv.FormatUint(uint64(key.gid), 10)
+	if g, err := user.LookupGroupId(gid); err == nil {
+		gid = g.Name
+	}
+	name := uid + ":" + gid
+	o[key] = name
+	return name
+}
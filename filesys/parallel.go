@@ -0,0 +1,187 @@
+package filesys
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/mlange-42/dirstat/tree"
+)
+
+// dirJob is a directory waiting to be read by a worker
+type dirJob[T any] struct {
+	path  string
+	entry fs.DirEntry
+	node  *tree.Tree[T]
+	depth int
+	state dirState
+}
+
+// dirResult is the content of a directory, as read by a worker
+type dirResult[T any] struct {
+	job     *dirJob[T]
+	entries []fs.DirEntry
+	state   dirState
+	err     error
+}
+
+// walkDirParallel descends path like walkDirRecursive, but reads directories
+// concurrently using a bounded pool of workers.
+//
+// Workers only read directories, stat their entries and read archives.
+// walkDirFn is always called from the calling goroutine, in the order of
+// entries returned by readDir, so the resulting tree has the same shape
+// as the one of walkDirRecursive.
+//
+// When ctx is cancelled, directories that are queued or being read are reported
+// to walkDirFn with the context's error, and the partial tree is returned.
+//
+// State is the state of the root directory at path.
+func walkDirParallel[T any](ctx context.Context, fsys fileSystem, path string, d fs.DirEntry, workers int, state dirState, walkDirFn WalkDirFunc[T]) (*tree.Tree[T], error) {
+	t, err := walkDirFn(path, d, nil, 0, nil)
+	if err != nil || !d.IsDir() {
+		if err == filepath.SkipDir {
+			err = nil
+		}
+		return t, err
+	}
+
+	jobs := make(chan *dirJob[T])
+	results := make(chan dirResult[T], workers)
+	quit := make(chan struct{})
+	defer close(quit)
+	defer close(jobs)
+
+	for i := 0; i < workers; i++ {
+		go readDirWorker(ctx, fsys, jobs, results, quit)
+	}
+
+	// The queue is processed last-in-first-out, which keeps it
+	// small as the walk proceeds depth-first.
+	queue := []*dirJob[T]{{path: path, entry: d, node: t, state: state}}
+	inFlight := map[*dirJob[T]]struct{}{}
+	pending := 1
+
+	for pending > 0 {
+		if ctx.Err() != nil {
+			return reportCancelled(ctx, t, queue, inFlight, walkDirFn)
+		}
+
+		var send chan<- *dirJob[T]
+		var next *dirJob[T]
+		if len(queue) > 0 {
+			send = jobs
+			next = queue[len(queue)-1]
+		}
+
+		select {
+		case <-ctx.Done():
+			return reportCancelled(ctx, t, queue, inFlight, walkDirFn)
+		case send <- next:
+			queue = queue[:len(queue)-1]
+			inFlight[next] = struct{}{}
+		case res := <-results:
+			pending--
+			job := res.job
+			delete(inFlight, job)
+			if res.err == nil && len(res.entries) == 0 {
+				// Second call, to report an empty directory.
+				if _, err = walkDirFn(job.path, job.entry, job.node, job.depth, errEmptyDir); err != nil {
+					return nil, err
+				}
+			}
+			if res.err != nil {
+				// Second call, to report ReadDir error.
+				_, err = walkDirFn(job.path, job.entry, job.node, job.depth, res.err)
+				if err != nil {
+					if err == filepath.SkipDir {
+						continue
+					}
+					return nil, err
+				}
+			}
+			for _, d1 := range res.entries {
+				path1 := fsys.Join(job.path, d1.Name())
+				t1, err := walkDirFn(path1, d1, job.node, job.depth+1, nil)
+				if err != nil {
+					if err == filepath.SkipDir {
+						continue
+					}
+					return nil, err
+				}
+				if d1.IsDir() {
+					queue = append(queue, &dirJob[T]{path: path1, entry: d1, node: t1, depth: job.depth + 1, state: res.state.enter(path1, d1)})
+					pending++
+				}
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// reportCancelled reports all directories that are queued or
+// being read to walkDirFn, with the context's error.
+func reportCancelled[T any](ctx context.Context, t *tree.Tree[T], queue []*dirJob[T], inFlight map[*dirJob[T]]struct{}, walkDirFn WalkDirFunc[T]) (*tree.Tree[T], error) {
+	for _, job := range queue {
+		// Second call, to report cancellation.
+		if _, err := walkDirFn(job.path, job.entry, job.node, job.depth, ctx.Err()); err != nil {
+			return nil, err
+		}
+	}
+	for job := range inFlight {
+		// Second call, to report cancellation.
+		if _, err := walkDirFn(job.path, job.entry, job.node, job.depth, ctx.Err()); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// readDirWorker reads directories from jobs until jobs is closed.
+func readDirWorker[T any](ctx context.Context, fsys fileSystem, jobs <-chan *dirJob[T], results chan<- dirResult[T], quit <-chan struct{}) {
+	for job := range jobs {
+		entries, state, err := readDir(fsys, job.path, job.state)
+		statEntries(fsys, job.path, entries, state.birth)
+		if state.archives {
+			readArchives(ctx, fsys, job.path, entries)
+		}
+		select {
+		case results <- dirResult[T]{job: job, entries: entries, state: state, err: err}:
+		case <-quit:
+			return
+		}
+	}
+}
+
+// statEntries replaces entries by their file info, so that
+// the potentially slow stat calls happen in the worker.
+// With birth, birth times are read if they require an extra stat call.
+// Entries that can't be stat'ed are kept. Resolved links are kept, but get their birth times.
+func statEntries(fsys fileSystem, dirname string, entries []fs.DirEntry, birth bool) {
+	stat := func(e fs.DirEntry) (fs.FileInfo, error) {
+		info, err := e.Info()
+		if err == nil && birth {
+			info = withBirthTime(fsys, fsys.Join(dirname, e.Name()), info)
+		}
+		return info, err
+	}
+	for i, e := range entries {
+		switch e := e.(type) {
+		case *linkDirEntry:
+			if birth {
+				e.info = withBirthTime(fsys, fsys.Join(dirname, e.Name()), e.info)
+			}
+			continue
+		case *sniffedEntry:
+			// Keep the detected MIME type.
+			if info, err := stat(e); err == nil {
+				e.DirEntry = &statDirEntry{info}
+			}
+			continue
+		}
+		if info, err := stat(e); err == nil {
+			entries[i] = &statDirEntry{info}
+		}
+	}
+}
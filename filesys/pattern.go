@@ -0,0 +1,138 @@
+package filesys
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// pattern is a gitignore-style pattern.
+//
+//   - Patterns are matched against slash-separated paths, relative to the scan root.
+//   - Patterns without a slash match the name of files and directories at any level.
+//   - Patterns with a leading or inner slash are matched against the full relative path.
+//   - A trailing slash matches only directories.
+//   - Within a path segment, glob syntax like '*', '?' and '[a-z]' is supported.
+//   - A segment '**' matches zero or more directories. A trailing '/**' matches everything inside.
+//   - A leading '!' negates the pattern, re-including paths excluded by a previous pattern.
+type pattern struct {
+	segments []segment
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// segment is a path segment of a pattern
+type segment struct {
+	glob glob.Glob
+	any  bool
+}
+
+// compilePattern compiles a gitignore-style pattern
+func compilePattern(str string) (pattern, error) {
+	p := pattern{}
+	orig := str
+
+	if strings.HasPrefix(str, "!") {
+		p.negate = true
+		str = str[1:]
+	} else if strings.HasPrefix(str, `\!`) {
+		str = str[1:]
+	}
+	if strings.HasSuffix(str, "/") {
+		p.dirOnly = true
+		str = strings.TrimRight(str, "/")
+	}
+	if strings.Contains(str, "/") {
+		p.anchored = true
+		str = strings.TrimPrefix(str, "/")
+	}
+	if len(str) == 0 {
+		return p, fmt.Errorf("invalid pattern '%s': empty pattern", orig)
+	}
+
+	for _, s := range strings.Split(str, "/") {
+		// A lone '**' is not anchored, and matches every name like '*'.
+		if s == "**" && p.anchored {
+			p.segments = append(p.segments, segment{any: true})
+			continue
+		}
+		g, err := glob.Compile(s)
+		if err != nil {
+			return p, fmt.Errorf("invalid pattern '%s': %s", orig, err)
+		}
+		p.segments = append(p.segments, segment{glob: g})
+	}
+
+	return p, nil
+}
+
+// match reports whether the pattern matches a slash-separated relative path.
+// Negation is not considered here.
+func (p *pattern) match(path string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if !p.anchored {
+		return p.segments[0].glob.Match(path[strings.LastIndexByte(path, '/')+1:])
+	}
+	return matchSegments(p.segments, strings.Split(path, "/"))
+}
+
+// matchSegments matches path segments against pattern segments
+func matchSegments(pattern []segment, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0].any {
+			if len(pattern) == 1 {
+				// Trailing '**' matches everything inside, but not the directory itself.
+				return len(path) > 0
+			}
+			for i := 0; i <= len(path); i++ {
+				if matchSegments(pattern[1:], path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 || !pattern[0].glob.Match(path[0]) {
+			return false
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0
+}
+
+// patterns is a list of gitignore-style patterns,
+// where the last matching pattern takes precedence
+type patterns []pattern
+
+// compilePatterns compiles a list of gitignore-style patterns
+func compilePatterns(strs []string) (patterns, error) {
+	ps := make(patterns, 0, len(strs))
+	for _, s := range strs {
+		p, err := compilePattern(s)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
+
+// match reports whether the last pattern matching path is not negated
+func (ps patterns) match(path string, isDir bool) bool {
+	result, _ := ps.decide(path, isDir)
+	return result
+}
+
+// decide reports whether the last pattern matching path is not negated,
+// and whether any pattern matched at all
+func (ps patterns) decide(path string, isDir bool) (bool, bool) {
+	for i := len(ps) - 1; i >= 0; i-- {
+		if ps[i].match(path, isDir) {
+			return !ps[i].negate, true
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,70 @@
+package filesys
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatternMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		match   bool
+	}{
+		{"tmp", "tmp", true, true},
+		{"tmp", "build/tmp", true, true},
+		{"tmp", "build/tmp/a.txt", false, false},
+		{"*.exe", "bin/a.exe", false, true},
+		{"*.exe", "bin/a.exe/b", false, false},
+		{"build/tmp", "build/tmp", true, true},
+		{"build/tmp", "src/build/tmp", true, false},
+		{"/build", "build", true, true},
+		{"/build", "src/build", true, false},
+		{"tmp/", "tmp", true, true},
+		{"tmp/", "tmp", false, false},
+		{"**/tmp", "tmp", true, true},
+		{"**/tmp", "a/b/tmp", true, true},
+		{"a/**/b", "a/b", true, true},
+		{"a/**/b", "a/x/y/b", true, true},
+		{"a/**/b", "a/x/y/c", true, false},
+		{"a/**", "a", true, false},
+		{"a/**", "a/x", false, true},
+		{"a/**", "a/x/y", false, true},
+		{"**", "a", false, true},
+		{"**", "a/b/c.txt", false, true},
+		{"**/", "a/b", true, true},
+		{"**/", "a/b.txt", false, false},
+		{"/**", "a/b.txt", false, true},
+		{"**/*.txt", "a/b.txt", false, true},
+		{"a/*/c", "a/b/c", true, true},
+		{"a/*/c", "a/b/b/c", true, false},
+		{"file-?.txt", "x/file-1.txt", false, true},
+		{"file-[0-2].txt", "file-3.txt", false, false},
+	}
+
+	for _, c := range cases {
+		p, err := compilePattern(c.pattern)
+		assert.Nil(t, err)
+		assert.Equal(t, c.match, p.match(c.path, c.isDir), "pattern '%s', path '%s'", c.pattern, c.path)
+	}
+}
+
+func TestPatternsNegate(t *testing.T) {
+	ps, err := compilePatterns([]string{"*.log", "!keep.log", `\!literal`})
+	assert.Nil(t, err)
+
+	assert.True(t, ps.match("a/debug.log", false))
+	assert.False(t, ps.match("a/keep.log", false))
+	assert.False(t, ps.match("a/b.txt", false))
+	assert.True(t, ps.match("!literal", false))
+}
+
+func TestPatternInvalid(t *testing.T) {
+	_, err := compilePatterns([]string{"*.go", "[a-"})
+	assert.NotNil(t, err)
+
+	_, err = compilePatterns([]string{"/"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,35 @@
+//go:build !unix
+
+package filesys
+
+import "io/fs"
+
+// diskSize returns the size allocated on disk.
+// Falls back to the apparent size, as block counts are not available on this platform
+func diskSize(info fs.FileInfo) int64 {
+	return info.Size()
+}
+
+// hardLinkID returns the device and inode of files with more than one hard link.
+// Always returns false, as inodes are not available on this platform
+func hardLinkID(info fs.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
+
+// fileIdentity returns the device and inode of a file.
+// Always returns false, as inodes are not available on this platform
+func fileIdentity(info fs.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
+
+// deviceID returns the ID of the device containing the file.
+// Always returns false, as device IDs are not available on this platform
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// ownerID returns the user and group owning the file.
+// Always returns false, as numeric owners are not available on this platform
+func ownerID(info fs.FileInfo) (ownerKey, bool) {
+	return ownerKey{}, false
+}
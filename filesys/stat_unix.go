@@ -0,0 +1,48 @@
+//go:build unix
+
+package filesys
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// diskSize returns the size allocated on disk, from the file's block count
+func diskSize(info fs.FileInfo) int64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int64(st.Blocks) * 512
+	}
+	return info.Size()
+}
+
+// hardLinkID returns the device and inode of files with more than one hard link
+func hardLinkID(info fs.FileInfo) (fileID, bool) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && st.Nlink > 1 {
+		return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+	}
+	return fileID{}, false
+}
+
+// fileIdentity returns the device and inode of a file
+func fileIdentity(info fs.FileInfo) (fileID, bool) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+	}
+	return fileID{}, false
+}
+
+// deviceID returns the ID of the device containing the file
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Dev), true
+	}
+	return 0, false
+}
+
+// ownerID returns the user and group owning the file
+func ownerID(info fs.FileInfo) (ownerKey, bool) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return ownerKey{uid: uint32(st.Uid), gid: uint32(st.Gid)}, true
+	}
+	return ownerKey{}, false
+}
@@ -0,0 +1,99 @@
+//go:build unix
+
+package filesys
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/mlange-42/dirstat/tree"
+	"github.com/stretchr/testify/assert"
+)
+
+// mountFS is the file system of the operating system,
+// with the directories in mounts reported on another device
+type mountFS struct {
+	osFS
+	mounts map[string]bool
+}
+
+func (f mountFS) Lstat(name string) (fs.FileInfo, error) {
+	info, err := f.osFS.Lstat(name)
+	return f.mount(name, info), err
+}
+
+func (f mountFS) Stat(name string) (fs.FileInfo, error) {
+	info, err := f.osFS.Stat(name)
+	return f.mount(name, info), err
+}
+
+func (f mountFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := f.osFS.ReadDir(name)
+	for i, e := range entries {
+		path := f.Join(name, e.Name())
+		if !f.mounts[path] {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			entries[i] = &statDirEntry{f.mount(path, info)}
+		}
+	}
+	return entries, err
+}
+
+// mount returns info with another device if name is in mounts
+func (f mountFS) mount(name string, info fs.FileInfo) fs.FileInfo {
+	if info == nil || !f.mounts[name] {
+		return info
+	}
+	st := *info.Sys().(*syscall.Stat_t)
+	st.Dev++
+	return &mountInfo{info, &st}
+}
+
+// mountInfo is a file info with a replaced stat structure
+type mountInfo struct {
+	fs.FileInfo
+	st *syscall.Stat_t
+}
+
+func (i *mountInfo) Sys() any { return i.st }
+
+func TestWalkOneFileSystem(t *testing.T) {
+	dir := createTestDir(t, 2, 2, 3)
+	fsys := mountFS{mounts: map[string]bool{
+		filepath.Join(dir, "Dir-1"):          true,
+		filepath.Join(dir, "Dir-0", "Dir-1"): true,
+	}}
+	walkMounts := func(opts Options) *tree.FileTree {
+		tr, err := collect(func(progress chan<- Progress, done chan<- *tree.FileTree, erro chan<- error) {
+			walkTree(context.Background(), fsys, dir, opts, progress, done, erro)
+		})
+		assert.Nil(t, err)
+		return tr
+	}
+
+	for _, workers := range []int{1, 4} {
+		tr := walkMounts(Options{MaxDepth: -1, Workers: workers})
+		assert.Equal(t, 7*3, tr.Value.Count)
+
+		tr = walkMounts(Options{MaxDepth: -1, Workers: workers, OneFileSystem: true})
+		assert.Equal(t, 3*3, tr.Value.Count)
+		assert.True(t, tr.Value.Incomplete)
+		for _, path := range [][]string{{"Dir-1"}, {"Dir-0", "Dir-1"}} {
+			mount := child(t, tr, path...)
+			assert.Equal(t, tree.UnscannedFileSystem, mount.Value.Unscanned)
+			assert.Equal(t, 0, mount.Value.Count)
+			assert.Equal(t, 0, len(mount.Children))
+		}
+
+		// Below the maximum depth, placeholders are kept in the deepest listed directory.
+		tr = walkMounts(Options{MaxDepth: 0, Workers: workers, OneFileSystem: true})
+		assert.Equal(t, 3*3, tr.Value.Count)
+		assert.ElementsMatch(t, []string{"Dir-0/Dir-1", "Dir-1"}, names(tr))
+		assert.Equal(t, tree.UnscannedFileSystem, child(t, tr, "Dir-0/Dir-1").Value.Unscanned)
+	}
+}
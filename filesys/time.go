@@ -0,0 +1,78 @@
+package filesys
+
+import (
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/mlange-42/dirstat/tree"
+)
+
+// normalizeTimeKind returns the kind of timestamp, with the modification time for an empty kind
+func normalizeTimeKind(kind string) (string, error) {
+	switch kind {
+	case "":
+		return tree.TimeModified, nil
+	case tree.TimeModified, tree.TimeAccessed, tree.TimeChanged, tree.TimeBirth:
+		return kind, nil
+	default:
+		return "", fmt.Errorf("unknown time '%s'. Must be one of [mtime, atime, ctime, btime]", kind)
+	}
+}
+
+// fileTime returns the timestamp of a file selected by kind.
+// Falls back to the modification time if the timestamp is not available on the platform or file system.
+func fileTime(fsys fileSystem, path string, info fs.FileInfo, kind string) time.Time {
+	if kind == tree.TimeModified {
+		return info.ModTime()
+	}
+	if _, ok := info.Sys().(*tree.FileEntry); ok {
+		// Reused from the baseline, which has the same kind of timestamp.
+		return info.ModTime()
+	}
+	if b, ok := info.(*birthInfo); ok {
+		return b.birth
+	}
+	if t, ok := statTime(info, kind); ok {
+		return t
+	}
+	if kind == tree.TimeBirth {
+		if t, ok := osBirthTime(fsys, path, info); ok {
+			return t
+		}
+	}
+	return info.ModTime()
+}
+
+// birthInfo is a file info with the birth time of the file, read by a worker
+type birthInfo struct {
+	fs.FileInfo
+	birth time.Time
+}
+
+// withBirthTime returns info of a file with its birth time, if it is not available from info itself,
+// so that the potentially slow extra stat call happens in the worker.
+func withBirthTime(fsys fileSystem, path string, info fs.FileInfo) fs.FileInfo {
+	if info.IsDir() {
+		return info
+	}
+	if _, ok := info.Sys().(*tree.FileEntry); ok {
+		return info
+	}
+	if _, ok := statTime(info, tree.TimeBirth); ok {
+		return info
+	}
+	if t, ok := osBirthTime(fsys, path, info); ok {
+		return &birthInfo{info, t}
+	}
+	return info
+}
+
+// osBirthTime returns the birth time of a file of the operating system, using an extra stat call.
+// Symbolic links are followed if info is of the link's target
+func osBirthTime(fsys fileSystem, path string, info fs.FileInfo) (time.Time, bool) {
+	if _, ok := fsys.(osFS); !ok {
+		return time.Time{}, false
+	}
+	return birthTime(path, info.Mode()&fs.ModeSymlink == 0)
+}
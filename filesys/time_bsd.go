@@ -0,0 +1,34 @@
+//go:build darwin || freebsd || netbsd
+
+package filesys
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+
+	"github.com/mlange-42/dirstat/tree"
+)
+
+// statTime returns the access, change or birth time of a file
+func statTime(info fs.FileInfo, kind string) (time.Time, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	switch kind {
+	case tree.TimeAccessed:
+		return time.Unix(int64(st.Atimespec.Sec), int64(st.Atimespec.Nsec)), true
+	case tree.TimeChanged:
+		return time.Unix(int64(st.Ctimespec.Sec), int64(st.Ctimespec.Nsec)), true
+	case tree.TimeBirth:
+		return time.Unix(int64(st.Birthtimespec.Sec), int64(st.Birthtimespec.Nsec)), true
+	}
+	return time.Time{}, false
+}
+
+// birthTime returns the birth time of a file.
+// Always returns false, as it is already available from statTime on this platform
+func birthTime(path string, follow bool) (time.Time, bool) {
+	return time.Time{}, false
+}
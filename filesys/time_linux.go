@@ -0,0 +1,44 @@
+package filesys
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+
+	"github.com/mlange-42/dirstat/tree"
+	"golang.org/x/sys/unix"
+)
+
+// statTime returns the access or change time of a file.
+// Birth times are not part of the stat structure, see birthTime
+func statTime(info fs.FileInfo, kind string) (time.Time, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	switch kind {
+	case tree.TimeAccessed:
+		return time.Unix(int64(st.Atim.Sec), int64(st.Atim.Nsec)), true
+	case tree.TimeChanged:
+		return time.Unix(int64(st.Ctim.Sec), int64(st.Ctim.Nsec)), true
+	}
+	return time.Time{}, false
+}
+
+// birthTime returns the birth time of a file, using statx.
+// Symbolic links are only followed if follow is true.
+// Returns false if the kernel or the file system does not provide it
+func birthTime(path string, follow bool) (time.Time, bool) {
+	flags := unix.AT_SYMLINK_NOFOLLOW
+	if follow {
+		flags = 0
+	}
+	var st unix.Statx_t
+	if err := unix.Statx(unix.AT_FDCWD, path, flags, unix.STATX_BTIME, &st); err != nil {
+		return time.Time{}, false
+	}
+	if st.Mask&unix.STATX_BTIME == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(st.Btime.Sec, int64(st.Btime.Nsec)), true
+}
@@ -0,0 +1,20 @@
+//go:build !unix && !windows
+
+package filesys
+
+import (
+	"io/fs"
+	"time"
+)
+
+// statTime returns the access, change or birth time of a file.
+// Always returns false, as these are not available on this platform
+func statTime(info fs.FileInfo, kind string) (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// birthTime returns the birth time of a file.
+// Always returns false, as it is not available on this platform
+func birthTime(path string, follow bool) (time.Time, bool) {
+	return time.Time{}, false
+}
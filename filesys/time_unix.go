@@ -0,0 +1,33 @@
+//go:build unix && !darwin && !freebsd && !netbsd && !linux
+
+package filesys
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+
+	"github.com/mlange-42/dirstat/tree"
+)
+
+// statTime returns the access or change time of a file.
+// Birth times are not available on this platform
+func statTime(info fs.FileInfo, kind string) (time.Time, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	switch kind {
+	case tree.TimeAccessed:
+		return time.Unix(int64(st.Atim.Sec), int64(st.Atim.Nsec)), true
+	case tree.TimeChanged:
+		return time.Unix(int64(st.Ctim.Sec), int64(st.Ctim.Nsec)), true
+	}
+	return time.Time{}, false
+}
+
+// birthTime returns the birth time of a file.
+// Always returns false, as birth times are not available on this platform
+func birthTime(path string, follow bool) (time.Time, bool) {
+	return time.Time{}, false
+}
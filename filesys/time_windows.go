@@ -0,0 +1,31 @@
+package filesys
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+
+	"github.com/mlange-42/dirstat/tree"
+)
+
+// statTime returns the access or creation time of a file.
+// Change times are not available on this platform
+func statTime(info fs.FileInfo, kind string) (time.Time, bool) {
+	attr, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, false
+	}
+	switch kind {
+	case tree.TimeAccessed:
+		return time.Unix(0, attr.LastAccessTime.Nanoseconds()), true
+	case tree.TimeBirth:
+		return time.Unix(0, attr.CreationTime.Nanoseconds()), true
+	}
+	return time.Time{}, false
+}
+
+// birthTime returns the birth time of a file.
+// Always returns false, as it is already available from statTime on this platform
+func birthTime(path string, follow bool) (time.Time, bool) {
+	return time.Time{}, false
+}
@@ -1,66 +1,288 @@
 package filesys
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/fs"
-	"os"
 	"path/filepath"
 	"sort"
-	"unicode"
-	"unicode/utf8"
+	"strings"
+	"time"
 
-	"github.com/gobwas/glob"
 	"github.com/mlange-42/dirstat/tree"
 )
 
-// Walk searches through a directory tree
-func Walk(dir string, exclude []string, maxDepth int, progres chan<- int64, done chan<- *tree.FileTree, erro chan<- error) {
-	excludeGlobs := make([]glob.Glob, 0, len(exclude))
-	for _, g := range exclude {
-		excludeGlobs = append(excludeGlobs, glob.MustCompile(g))
+// Options for walking a directory tree
+type Options struct {
+	Exclude       []string    // Gitignore-style exclusion patterns, matched against paths relative to the root
+	Include       []string    // Gitignore-style inclusion patterns for files. Includes all files if empty
+	MaxDepth      int         // Depth of the generated tree. Deeper entries are aggregated. -1 for unlimited depth
+	MaxScanDepth  int         // Depth of directories that are not read, but added as placeholders. 0 for unlimited depth
+	Workers       int         // Number of directories to read concurrently. 1 for a sequential walk
+	Strict        bool        // Abort on the first path that can't be read, instead of recording it in the tree
+	FollowLinks   bool        // Follow symbolic links, except for those resulting in a cycle
+	OneFileSystem bool        // Don't descend into directories on other file systems than the root. Adds placeholders instead
+	Ignore        IgnoreMode  // How to use ignore files (.gitignore and .dirstatignore) in all directories
+	Archives      bool        // Expand zip, jar, tar and tar.gz archives to virtual directories
+	Baseline      *Baseline   // Previous analysis for reusing listings of unchanged directories. Nil for a full scan
+	Categories    *Categories // Categories of files by extension and MIME type. Nil to skip categorization
+	Sniff         bool        // Detect MIME types of files from their first 512 bytes, for categories
+	Time          string      // Timestamp of files, one of the tree.Time... constants. Empty for the modification time
+	TopFiles      int         // Number of largest files to record in the root, independent of MaxDepth. 0 to skip
+}
+
+// Progress is sent for each scanned entry, and for each path that could not be read
+type Progress struct {
+	Size    int64 // Size of the entry
+	Skipped bool  // Whether the path was skipped as it could not be read
+}
+
+// Walk searches through a directory tree.
+// With more than one worker, directories are read concurrently.
+//
+// Files with multiple hard links are only charged once, to the lowest of their paths.
+// Sizes of further links are recorded as linked bytes.
+// With FollowLinks, the same applies to files reached through several paths,
+// preferring paths that are not reached through a followed link.
+// Disk sizes include the blocks of directories themselves, like for du.
+//
+// When ctx is cancelled, the walk stops and the partial tree is sent to done.
+// Directories that were not read completely, as well as their parents, are marked as incomplete.
+//
+// Paths that can't be read are recorded in the errors of their parent directory,
+// unless in strict mode.
+//
+// Directories on other file systems (with OneFileSystem) and at MaxScanDepth are not read,
+// and are added as placeholders with the reason in Unscanned. Their parents are marked as incomplete.
+//
+// With archives enabled, supported archives are expanded to virtual directories.
+// Inside them, the apparent size is the uncompressed size, and the disk size the compressed size.
+// Timestamps that archives don't record fall back to the modification time, like birth times.
+// Archives with multiple hard links, or reached through a followed link, are not expanded.
+// Archives that can't be read are treated as ordinary files, and are recorded like paths that can't be read.
+func Walk(ctx context.Context, dir string, opts Options, progres chan<- Progress, done chan<- *tree.FileTree, erro chan<- error) {
+	walkTree(ctx, osFS{}, dir, opts, progres, done, erro)
+}
+
+// WalkFS searches through a directory tree of fsys, like Walk.
+//
+// Paths are slash-separated and unrooted, as for all fs.FS. Use "." for the root of fsys.
+// Symbolic links are only recognized if fsys implements ReadLinkFS.
+// Hard links and file systems are only detected for files of the operating system.
+func WalkFS(ctx context.Context, fsys fs.FS, root string, opts Options, progres chan<- Progress, done chan<- *tree.FileTree, erro chan<- error) {
+	walkTree(ctx, ioFS{fsys}, root, opts, progres, done, erro)
+}
+
+// walkTree searches through a directory tree of fsys
+func walkTree(ctx context.Context, fsys fileSystem, dir string, opts Options, progres chan<- Progress, done chan<- *tree.FileTree, erro chan<- error) {
+	exclude, err := compilePatterns(opts.Exclude)
+	if err != nil {
+		erro <- err
+		return
 	}
+	include, err := compilePatterns(opts.Include)
+	if err != nil {
+		erro <- err
+		return
+	}
+	timeKind, err := normalizeTimeKind(opts.Time)
+	if err != nil {
+		erro <- err
+		return
+	}
+	opts.Time = timeKind
 
+	scanTime := time.Now()
 	anyFound := false
+	hardLinks := map[fileID][]linkedFile{}
+	followedDirs := map[string]bool{} // Directories reached through followed links, by relative path
+	largest := largestFiles{n: opts.TopFiles}
+	owners := ownerNames{}
+	var rootDevice uint64 = 0
 
-	t, err := walkDir(dir,
+	// skip records an unreadable path in parent, or returns the error in strict mode.
+	skip := func(path string, parent *tree.FileTree, err error) error {
+		if opts.Strict || parent == nil {
+			return err
+		}
+		parent.Value.Errors = append(parent.Value.Errors, tree.PathError{Path: path, Kind: errorKind(err)})
+		progres <- Progress{Skipped: true}
+		return nil
+	}
+
+	// unscanned adds a placeholder for a directory that is not read to parent.
+	// Parent is marked as incomplete, as its totals are only a lower bound.
+	unscanned := func(path string, info fs.FileInfo, parent *tree.FileTree, depth int, link string, reason string) {
+		name := info.Name()
+		if opts.MaxDepth >= 0 && depth > opts.MaxDepth {
+			// Keep placeholders visible, relative to the deepest listed directory.
+			name = lastElements(path, depth-opts.MaxDepth)
+		}
+		placeholder := tree.NewDir(name)
+		placeholder.Value.Link = link
+		placeholder.Value.Unscanned = reason
+		parent.AddTree(placeholder)
+		parent.Value.Incomplete = true
+	}
+
+	t, err := walkDir(ctx, fsys, dir, opts,
 		func(path string, d fs.DirEntry, parent *tree.FileTree, depth int, err error) (*tree.FileTree, error) {
+			if err != nil && err == ctx.Err() {
+				parent.Value.Incomplete = true
+				return nil, nil
+			}
+			if err == errEmptyDir {
+				parent.Value.Empty++
+				return nil, nil
+			}
 			if err != nil {
-				erro <- err
-				return nil, err
+				// Reading a directory failed. Entries read so far are still used.
+				return nil, skip(path, parent, err)
 			}
-			for _, g := range excludeGlobs {
-				if g.Match(d.Name()) {
+			if parent != nil {
+				rel := relativePath(dir, path)
+				if exclude.match(rel, d.IsDir()) {
+					return nil, fs.SkipDir
+				}
+				if len(include) > 0 && !d.IsDir() && !include.match(rel, false) {
 					return nil, fs.SkipDir
 				}
 			}
 			info, err := d.Info()
 			if err != nil {
-				return nil, err
+				if err = skip(path, parent, err); err != nil {
+					return nil, err
+				}
+				return nil, fs.SkipDir
 			}
 			anyFound = true
+			link := linkTarget(fsys, path, d)
+
+			if opts.OneFileSystem && info.IsDir() {
+				dev, ok := deviceID(info)
+				if parent == nil {
+					rootDevice = dev
+				} else if ok && dev != rootDevice {
+					unscanned(path, info, parent, depth, link, tree.UnscannedFileSystem)
+					return nil, fs.SkipDir
+				}
+			}
+			if opts.MaxScanDepth > 0 && depth >= opts.MaxScanDepth && info.IsDir() {
+				unscanned(path, info, parent, depth, link, tree.UnscannedDepth)
+				return nil, fs.SkipDir
+			}
 
+			owner := owners.name(info)
+			size, disk, linked := info.Size(), diskSize(info), int64(0)
+			special := info.Mode()&specialModes != 0
+			category := ""
 			if !info.IsDir() {
-				v := parent.Value
-				ext := filepath.Ext(info.Name())
-				if inf, ok := v.Extensions[ext]; ok {
-					inf.Add(info.Size(), 1, info.ModTime())
-				} else {
-					e := tree.ExtensionEntry{Name: ext, Size: info.Size(), Count: 1, Time: info.ModTime()}
-					v.Extensions[ext] = &e
+				category = opts.Categories.category(info.Name(), mimeType(d))
+			}
+			if e, ok := info.Sys().(*tree.FileEntry); ok {
+				// Reused from the baseline. Files with multiple hard links are stat'ed again, see baselineEntry.
+				size, disk, linked, category = e.Size, e.Disk, e.Linked, e.Category
+				special = e.Special > 0
+			}
+			_, isLink := d.(*linkDirEntry)
+			if isLink && info.IsDir() {
+				followedDirs[relativePath(dir, path)] = true
+			}
+			file := &tree.FileEntry{}
+			if !info.IsDir() {
+				rel := relativePath(dir, path)
+				id, hardLinked := hardLinkID(info)
+				shared, followed := hardLinked, false
+				if opts.FollowLinks {
+					// Any file may be reached through several paths.
+					id, shared = fileIdentity(info)
+					followed = isLink || inFollowedDir(rel, followedDirs)
+				}
+				timestamp := fileTime(fsys, path, info, timeKind)
+				if !shared {
+					// Shared files are added when their owner is known, see chargeHardLinks.
+					largest.add(tree.LargeFile{Path: rel, Size: size, Disk: disk, Time: timestamp})
+				}
+
+				format := ""
+				if opts.Archives && !hardLinked && !followed && linked == 0 && parent != nil {
+					format = archiveFormat(info.Name())
+				}
+				if len(format) > 0 {
+					entries, err := archiveContent(ctx, fsys, path, d, format, size)
+					if len(entries) > 0 {
+						progres <- Progress{Size: size}
+						if shared {
+							// Charged to the expanded archive, see chargeHardLinks.
+							archive := tree.NewFileEntry(info.Name(), size, disk, timestamp, false)
+							hardLinks[id] = append(hardLinks[id], linkedFile{path: rel, file: &archive})
+						}
+
+						node := parent
+						if opts.MaxDepth < 0 || depth <= opts.MaxDepth {
+							node = tree.NewDir(info.Name())
+							node.Value.Archive = format
+							node.Value.Link = link
+							parent.AddTree(node)
+						}
+						node.Value.Owner = owner
+						addArchive(node, entries, owner, opts.Categories, depth, opts.MaxDepth, disk, timeKind, scanTime)
+
+						if err != nil && err == ctx.Err() {
+							node.Value.Incomplete = true
+						} else if err != nil {
+							return nil, skip(path, node, err)
+						}
+						return nil, nil
+					}
+					if err != nil && err != ctx.Err() {
+						// Archives that can't be read are treated as ordinary files.
+						if err := skip(path, parent, err); err != nil {
+							return nil, err
+						}
+					}
+				}
+
+				*file = tree.NewFileEntry(info.Name(), size, disk, timestamp, false)
+				file.Linked, file.Link, file.Owner, file.Category = linked, link, owner, category
+				file.HardLinked = hardLinked
+				if len(link) > 0 {
+					file.Links = 1
+				} else if special {
+					file.Special = 1
+				}
+				addFile(parent.Value, file, scanTime)
+				parent.Value.Linked += linked
+				if shared {
+					hardLinks[id] = append(hardLinks[id], linkedFile{path: rel, file: file, dir: parent.Value, followed: followed})
+					if len(hardLinks[id]) > 1 {
+						// Only report the first path to progress.
+						size = 0
+					}
 				}
-				v.Add(info.Size(), 1, info.ModTime())
 			}
 
-			progres <- info.Size()
+			progres <- Progress{Size: size}
 
-			if maxDepth >= 0 && depth > maxDepth {
+			if opts.MaxDepth >= 0 && depth > opts.MaxDepth {
+				if info.IsDir() {
+					parent.Value.Dirs++
+					parent.Value.Disk += disk
+				}
 				return parent, nil
 			}
 			var subTree *tree.FileTree
 			if info.IsDir() {
 				subTree = tree.NewDir(info.Name())
+				subTree.Value.Dirs = 1
+				subTree.Value.Disk = disk
+				modTime := info.ModTime()
+				subTree.Value.DirTime = &modTime
+				subTree.Value.Link = link
+				subTree.Value.Owner = owner
 			} else {
-				subTree = tree.NewFile(info.Name(), info.Size(), info.ModTime())
+				subTree = tree.New(file)
 			}
 
 			if parent != nil {
@@ -74,28 +296,129 @@ func Walk(dir string, exclude []string, maxDepth int, progres chan<- int64, done
 		return
 	}
 
-	if !anyFound {
+	if ctx.Err() != nil {
+		t.Value.Incomplete = true
+	} else if !anyFound {
 		erro <- fmt.Errorf("Nothing found in directoy %s", dir)
 		return
 	}
 
+	chargeHardLinks(hardLinks, &largest)
+	t.Value.TimeKind = timeKind
+	t.Value.ScanTime = &scanTime
+	t.Value.Largest = largest.sorted()
 	t.Aggregate(func(parent, child *tree.FileEntry) {
 		if child.IsDir {
-			parent.Add(child.Size, child.Count, child.Time)
+			parent.Add(child.Size, child.Disk, child.Count, child.Time)
+			parent.AddAges(child.Oldest, child.Ages)
+			parent.AddCounts(child)
+			parent.Linked += child.Linked
+		}
+		if child.Incomplete {
+			parent.Incomplete = true
 		}
 	})
 
 	done <- t
 }
 
+// specialModes are the file modes of special files, like named pipes, sockets and devices
+const specialModes = fs.ModeNamedPipe | fs.ModeSocket | fs.ModeDevice | fs.ModeCharDevice | fs.ModeIrregular
+
+// errEmptyDir is passed to WalkDirFunc in the second call for directories without entries
+var errEmptyDir = errors.New("empty directory")
+
+// fileID identifies a file by device and inode
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// linkedFile is a path of a file with multiple hard links, or of a file that may be reached through followed links
+type linkedFile struct {
+	path     string          // Path relative to the root of the walk
+	file     *tree.FileEntry // The file's entry
+	dir      *tree.FileEntry // The directory the file was added to. Nil for expanded archives
+	followed bool            // Whether the path is reached through a followed link
+}
+
+// chargeHardLinks charges each file with multiple paths to the lowest of its paths,
+// independent of the order of the walk, and adds it to the largest files.
+// Expanded archives come first, followed by paths not reached through a followed link.
+// Sizes of the other paths are moved to linked bytes, in the files as well as in their directories.
+func chargeHardLinks(hardLinks map[fileID][]linkedFile, largest *largestFiles) {
+	for _, files := range hardLinks {
+		sort.Slice(files, func(i, j int) bool {
+			a, b := files[i], files[j]
+			if (a.dir == nil) != (b.dir == nil) {
+				return a.dir == nil
+			}
+			if a.followed != b.followed {
+				return !a.followed
+			}
+			return a.path < b.path
+		})
+		f := files[0].file
+		largest.add(tree.LargeFile{Path: files[0].path, Size: f.Size, Disk: f.Disk, Time: f.Time})
+
+		for _, l := range files[1:] {
+			f := l.file
+			l.dir.Size -= f.Size
+			l.dir.Disk -= f.Disk
+			l.dir.Linked += f.Size
+			unlinkEntry(l.dir.Extensions, filepath.Ext(f.Name), f)
+			unlinkEntry(l.dir.Owners, f.Owner, f)
+			unlinkEntry(l.dir.Categories, f.Category, f)
+			f.Size, f.Disk, f.Linked = 0, 0, f.Size
+		}
+	}
+}
+
+// inFollowedDir reports whether the slash-separated relative path is inside one of the followed directories
+func inFollowedDir(rel string, followed map[string]bool) bool {
+	if len(followed) == 0 {
+		return false
+	}
+	for i := strings.LastIndexByte(rel, '/'); i > 0; i = strings.LastIndexByte(rel, '/') {
+		rel = rel[:i]
+		if followed[rel] {
+			return true
+		}
+	}
+	return false
+}
+
+// unlinkEntry removes the sizes of file f from the entry with the given name, but keeps it counted
+func unlinkEntry(entries map[string]*tree.ExtensionEntry, name string, f *tree.FileEntry) {
+	if e, ok := entries[name]; ok {
+		e.Size -= f.Size
+		e.Disk -= f.Disk
+	}
+}
+
 // walkDir recursively descends path, calling walkDirFn.
-func walkDir[T any](root string, fn WalkDirFunc[T]) (*tree.Tree[T], error) {
-	info, err := os.Lstat(root)
+// Uses the parallel walker for more than one worker.
+func walkDir[T any](ctx context.Context, fsys fileSystem, root string, opts Options, fn WalkDirFunc[T]) (*tree.Tree[T], error) {
+	stat := fsys.Lstat
+	if opts.FollowLinks {
+		stat = fsys.Stat
+	}
+
+	info, err := stat(root)
 	var t *tree.Tree[T] = nil
 	if err != nil {
 		t, err = fn(root, nil, nil, 0, err)
 	} else {
-		t, err = walkDirRecursive(root, &statDirEntry{info}, nil, 0, fn)
+		d := &statDirEntry{info}
+		state := dirState{ignore: newIgnoreRules(opts.Ignore, root), baseline: opts.Baseline.root(d, opts.Time), sniff: opts.Sniff && opts.Categories != nil, archives: opts.Archives, birth: opts.Time == tree.TimeBirth}
+		if opts.FollowLinks {
+			state.links = enterDir(&dirChain{}, d)
+		}
+		if opts.Workers > 1 {
+			t, err = walkDirParallel(ctx, fsys, root, d, opts.Workers, state, fn)
+		} else {
+			t, err = walkDirRecursive(ctx, fsys, root, d, nil, 0, state, fn)
+		}
 	}
 	if err == filepath.SkipDir {
 		return t, nil
@@ -103,7 +426,12 @@ func walkDir[T any](root string, fn WalkDirFunc[T]) (*tree.Tree[T], error) {
 	return t, err
 }
 
-// WalkDirFunc as callback for WalkDir
+// WalkDirFunc as callback for WalkDir.
+//
+// For directories, it is called a second time with a non-nil err if reading the directory fails,
+// with the context's error if the walk is cancelled before the directory is read completely,
+// or with errEmptyDir if the directory has no entries.
+// In the second call, parent is the tree returned by the first call.
 type WalkDirFunc[T any] func(path string, d fs.DirEntry, parent *tree.Tree[T], depth int, err error) (*tree.Tree[T], error)
 
 type statDirEntry struct {
@@ -116,7 +444,8 @@ func (d *statDirEntry) Type() fs.FileMode          { return d.info.Mode().Type()
 func (d *statDirEntry) Info() (fs.FileInfo, error) { return d.info, nil }
 
 // walkDirRecursive recursively descends path, calling walkDirFn.
-func walkDirRecursive[T any](path string, d fs.DirEntry, parent *tree.Tree[T], depth int, walkDirFn WalkDirFunc[T]) (*tree.Tree[T], error) {
+// State is the state of the directory at path.
+func walkDirRecursive[T any](ctx context.Context, fsys fileSystem, path string, d fs.DirEntry, parent *tree.Tree[T], depth int, state dirState, walkDirFn WalkDirFunc[T]) (*tree.Tree[T], error) {
 	t, err := walkDirFn(path, d, parent, depth, nil)
 	if err != nil || !d.IsDir() {
 		if err == filepath.SkipDir {
@@ -126,10 +455,21 @@ func walkDirRecursive[T any](path string, d fs.DirEntry, parent *tree.Tree[T], d
 		return t, err
 	}
 
-	dirs, err := readDir(path)
+	if ctx.Err() != nil {
+		// Second call, to report cancellation.
+		_, err = walkDirFn(path, d, t, depth, ctx.Err())
+		return t, err
+	}
+
+	dirs, state, err := readDir(fsys, path, state)
+	if err == nil && len(dirs) == 0 {
+		// Second call, to report an empty directory.
+		_, err = walkDirFn(path, d, t, depth, errEmptyDir)
+		return t, err
+	}
 	if err != nil {
 		// Second call, to report ReadDir error.
-		_, err = walkDirFn(path, d, parent, depth, err)
+		_, err = walkDirFn(path, d, t, depth, err)
 		if err != nil {
 			if err == filepath.SkipDir && d.IsDir() {
 				err = nil
@@ -139,8 +479,13 @@ func walkDirRecursive[T any](path string, d fs.DirEntry, parent *tree.Tree[T], d
 	}
 
 	for _, d1 := range dirs {
-		path1 := filepath.Join(path, d1.Name())
-		_, err := walkDirRecursive(path1, d1, t, depth+1, walkDirFn)
+		if ctx.Err() != nil {
+			// Second call, to report cancellation.
+			_, err = walkDirFn(path, d, t, depth, ctx.Err())
+			return t, err
+		}
+		path1 := fsys.Join(path, d1.Name())
+		_, err := walkDirRecursive(ctx, fsys, path1, d1, t, depth+1, state.enter(path1, d1), walkDirFn)
 		if err != nil && err != filepath.SkipDir {
 			return nil, err
 		}
@@ -150,55 +495,82 @@ func walkDirRecursive[T any](path string, d fs.DirEntry, parent *tree.Tree[T], d
 
 // readDir reads the directory named by dirname and returns
 // a sorted list of directory entries.
-func readDir(dirname string) ([]fs.DirEntry, error) {
-	f, err := os.Open(dirname)
-	if err != nil {
-		if _, ok := err.(*os.PathError); ok {
-			return []fs.DirEntry{}, nil
-		}
-		panic(err)
+// On error, it returns the entries read before the error.
+//
+// According to the directory's state, symbolic links are resolved, except for those resulting in a cycle,
+// and entries are filtered using ignore files.
+// Returns the state for sub-directories.
+func readDir(fsys fileSystem, dirname string, state dirState) ([]fs.DirEntry, dirState, error) {
+	dirs, err := state.baseline.readDir(fsys, dirname, state.links != nil)
+	if dirs == nil && err != nil {
+		return nil, state, err
 	}
-	dirs, err := f.ReadDir(-1)
-	f.Close()
-	if err != nil {
-		return nil, err
+	if state.links != nil {
+		resolveLinks(fsys, dirname, dirs, state.links)
+	}
+	dirs, state.ignore = state.ignore.filter(fsys, dirname, dirs)
+	if state.sniff {
+		sniffEntries(fsys, dirname, dirs)
 	}
 	sort.Slice(dirs,
 		func(i, j int) bool {
-			if dirs[i].IsDir() && !dirs[j].IsDir() {
-				return true
-			}
-			if !dirs[i].IsDir() && dirs[j].IsDir() {
-				return false
-			}
-			return lessCaseInsensitive(dirs[i].Name(), dirs[j].Name())
+			return tree.LessFile(dirs[i].Name(), dirs[i].IsDir(), dirs[j].Name(), dirs[j].IsDir())
 		})
-	return dirs, nil
+	return dirs, state, err
 }
 
-// lessCaseInsensitive compares s, t without allocating
-func lessCaseInsensitive(s, t string) bool {
-	for {
-		if len(t) == 0 {
-			return false
-		}
-		if len(s) == 0 {
-			return true
-		}
-		c, sizec := utf8.DecodeRuneInString(s)
-		d, sized := utf8.DecodeRuneInString(t)
+// relativePath returns the slash-separated path of path, relative to root
+func relativePath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
 
-		lowerc := unicode.ToLower(c)
-		lowerd := unicode.ToLower(d)
+// lastElements returns the last n elements of path, separated by slashes
+func lastElements(path string, n int) string {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	if n > len(parts) {
+		n = len(parts)
+	}
+	return strings.Join(parts[len(parts)-n:], "/")
+}
 
-		if lowerc < lowerd {
-			return true
-		}
-		if lowerc > lowerd {
-			return false
-		}
+// errorKind classifies errors for recording them in the tree
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		return tree.ErrorPermission
+	case errors.Is(err, fs.ErrNotExist):
+		return tree.ErrorNotExist
+	default:
+		return tree.ErrorOther
+	}
+}
+
+// addFile adds a file to the totals of directory v, as well as to its extensions, owners and categories.
+// Ages are relative to scanTime, the time of the analysis.
+func addFile(v *tree.FileEntry, f *tree.FileEntry, scanTime time.Time) {
+	addEntry(v.Extensions, filepath.Ext(f.Name), f, scanTime)
+	if len(f.Owner) > 0 {
+		addEntry(v.Owners, f.Owner, f, scanTime)
+	}
+	if len(f.Category) > 0 {
+		addEntry(v.Categories, f.Category, f, scanTime)
+	}
+	v.Add(f.Size, f.Disk, 1, f.Time)
+	v.AddAge(f.Time, scanTime)
+	v.AddCounts(f)
+}
 
-		s = s[sizec:]
-		t = t[sized:]
+// addEntry adds file f to the entry with the given name
+func addEntry(entries map[string]*tree.ExtensionEntry, name string, f *tree.FileEntry, scanTime time.Time) {
+	e, ok := entries[name]
+	if !ok {
+		e = &tree.ExtensionEntry{Name: name}
+		entries[name] = e
 	}
+	e.Add(f.Size, f.Disk, 1, f.Time)
+	e.AddAge(f.Time, scanTime)
 }
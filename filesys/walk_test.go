@@ -0,0 +1,542 @@
+package filesys
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/mlange-42/dirstat/tree"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/maps"
+)
+
+// createTestDir creates a directory tree with the given number of
+// levels, sub-directories per directory and files per directory.
+func createTestDir(tb testing.TB, levels, dirs, files int) string {
+	root := tb.TempDir()
+	var create func(dir string, level int)
+	create = func(dir string, level int) {
+		for i := 0; i < files; i++ {
+			name := filepath.Join(dir, fmt.Sprintf("file-%d.txt", i))
+			if err := os.WriteFile(name, make([]byte, i*10), 0644); err != nil {
+				tb.Fatal(err)
+			}
+		}
+		if level >= levels {
+			return
+		}
+		for i := 0; i < dirs; i++ {
+			sub := filepath.Join(dir, fmt.Sprintf("Dir-%d", i))
+			if err := os.Mkdir(sub, 0755); err != nil {
+				tb.Fatal(err)
+			}
+			create(sub, level+1)
+		}
+	}
+	create(root, 0)
+	return root
+}
+
+// createTestFS creates an in-memory file system with the same structure as createTestDir
+func createTestFS(levels, dirs, files int) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	var create func(dir string, level int)
+	create = func(dir string, level int) {
+		for i := 0; i < files; i++ {
+			fsys[path.Join(dir, fmt.Sprintf("file-%d.txt", i))] = &fstest.MapFile{Data: make([]byte, i*10)}
+		}
+		if level >= levels {
+			return
+		}
+		for i := 0; i < dirs; i++ {
+			sub := path.Join(dir, fmt.Sprintf("Dir-%d", i))
+			fsys[sub] = &fstest.MapFile{Mode: fs.ModeDir | 0755}
+			create(sub, level+1)
+		}
+	}
+	create(".", 0)
+	return fsys
+}
+
+// walk runs Walk and collects the result
+func walk(ctx context.Context, dir string, exclude []string, depth int, workers int) (*tree.FileTree, error) {
+	return walkOpts(ctx, dir, Options{Exclude: exclude, MaxDepth: depth, Workers: workers})
+}
+
+// walkOpts runs Walk with options and collects the result
+func walkOpts(ctx context.Context, dir string, opts Options) (*tree.FileTree, error) {
+	return collect(func(progress chan<- Progress, done chan<- *tree.FileTree, erro chan<- error) {
+		Walk(ctx, dir, opts, progress, done, erro)
+	})
+}
+
+// walkFS runs WalkFS with options and collects the result
+func walkFS(ctx context.Context, fsys fs.FS, root string, opts Options) (*tree.FileTree, error) {
+	return collect(func(progress chan<- Progress, done chan<- *tree.FileTree, erro chan<- error) {
+		WalkFS(ctx, fsys, root, opts, progress, done, erro)
+	})
+}
+
+// collect runs a walk function and collects the result
+func collect(fn func(progress chan<- Progress, done chan<- *tree.FileTree, erro chan<- error)) (*tree.FileTree, error) {
+	progress := make(chan Progress, 32)
+	done := make(chan *tree.FileTree)
+	erro := make(chan error)
+
+	go fn(progress, done, erro)
+
+	for {
+		select {
+		case <-progress:
+		case t := <-done:
+			return t, nil
+		case err := <-erro:
+			return nil, err
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	dir := createTestDir(t, 2, 2, 3)
+
+	tr, err := walk(context.Background(), dir, []string{}, -1, 1)
+	assert.Nil(t, err)
+
+	assert.Equal(t, filepath.Base(dir), tr.Value.Name)
+	assert.Equal(t, 7*3, tr.Value.Count)
+	assert.Equal(t, int64(7*30), tr.Value.Size)
+	assert.Equal(t, 5, len(tr.Children))
+	assert.Equal(t, "Dir-0", tr.Children[0].Value.Name)
+	assert.Equal(t, "Dir-1", tr.Children[1].Value.Name)
+	assert.Equal(t, "file-0.txt", tr.Children[2].Value.Name)
+}
+
+func TestWalkFS(t *testing.T) {
+	fsys := createTestFS(2, 2, 3)
+	fsys["Dir-1/.gitignore"] = &fstest.MapFile{Data: []byte("file-0.txt\n")}
+
+	for _, workers := range []int{1, 4} {
+		tr, err := walkFS(context.Background(), fsys, ".", Options{MaxDepth: -1, Workers: workers})
+		assert.Nil(t, err)
+
+		assert.Equal(t, 7*3+1, tr.Value.Count)
+		assert.Equal(t, int64(7*30+11), tr.Value.Size)
+		assert.Equal(t, []string{"Dir-0", "Dir-1", "file-0.txt", "file-1.txt", "file-2.txt"}, names(tr))
+		assert.Equal(t, []string{"Dir-0", "Dir-1", ".gitignore", "file-0.txt", "file-1.txt", "file-2.txt"}, names(child(t, tr, "Dir-1")))
+
+		tr, err = walkFS(context.Background(), fsys, "Dir-1", Options{MaxDepth: 1, Workers: workers, Exclude: []string{"Dir-0/"}, Ignore: IgnoreRespect})
+		assert.Nil(t, err)
+
+		assert.Equal(t, "Dir-1", tr.Value.Name)
+		assert.Equal(t, 3+2, tr.Value.Count)
+		assert.Equal(t, []string{"Dir-1", ".gitignore", "file-1.txt", "file-2.txt"}, names(tr))
+	}
+
+	_, err := walkFS(context.Background(), fsys, "missing", Options{MaxDepth: -1, Workers: 1})
+	assert.NotNil(t, err)
+}
+
+func TestWalkDiskSize(t *testing.T) {
+	dir := createTestDir(t, 2, 2, 3)
+
+	var disk int64
+	err := filepath.Walk(dir, func(path string, info fs.FileInfo, err error) error {
+		disk += diskSize(info)
+		return err
+	})
+	assert.Nil(t, err)
+
+	for _, depth := range []int{-1, 0} {
+		tr, err := walk(context.Background(), dir, []string{}, depth, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, disk, tr.Value.Disk)
+	}
+}
+
+func TestWalkParallel(t *testing.T) {
+	dir := createTestDir(t, 3, 3, 5)
+
+	for _, depth := range []int{-1, 0, 1, 2} {
+		seq, err := walk(context.Background(), dir, []string{"*-2.txt"}, depth, 1)
+		assert.Nil(t, err)
+
+		for _, workers := range []int{2, 8} {
+			par, err := walk(context.Background(), dir, []string{"*-2.txt"}, depth, workers)
+			assert.Nil(t, err)
+			par.Value.ScanTime = seq.Value.ScanTime
+			assert.Equal(t, seq, par)
+		}
+	}
+}
+
+func TestWalkPatterns(t *testing.T) {
+	dir := createTestDir(t, 2, 2, 3)
+
+	tr, err := walkOpts(context.Background(), dir, Options{Exclude: []string{"Dir-0/Dir-1"}, MaxDepth: -1, Workers: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 6*3, tr.Value.Count)
+	assert.Equal(t, 1+3, len(tr.Children[0].Children))
+
+	tr, err = walkOpts(context.Background(), dir, Options{Include: []string{"*-0.txt", "/Dir-1/**", "!**/Dir-1/file-2.txt"}, MaxDepth: -1, Workers: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 7+3*2-2, tr.Value.Count)
+
+	_, err = walkOpts(context.Background(), dir, Options{Exclude: []string{"[a-"}, MaxDepth: -1, Workers: 1})
+	assert.NotNil(t, err)
+}
+
+func TestWalkCancel(t *testing.T) {
+	dir := createTestDir(t, 2, 2, 3)
+
+	for _, workers := range []int{1, 4} {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		tr, err := walk(ctx, dir, []string{}, -1, workers)
+		assert.Nil(t, err)
+
+		assert.True(t, tr.Value.Incomplete)
+		assert.Equal(t, 0, len(tr.Children))
+		assert.Equal(t, 0, tr.Value.Count)
+	}
+}
+
+func TestWalkUnreadable(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced")
+	}
+	dir := createTestDir(t, 1, 2, 3)
+	locked := filepath.Join(dir, "Dir-1")
+	assert.Nil(t, os.Chmod(locked, 0))
+	defer os.Chmod(locked, 0755)
+
+	for _, workers := range []int{1, 4} {
+		tr, err := walk(context.Background(), dir, []string{}, -1, workers)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 2*3, tr.Value.Count)
+		assert.Equal(t, []tree.PathError{{Path: locked, Kind: tree.ErrorPermission}}, tr.Children[1].Value.Errors)
+
+		_, err = walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: workers, Strict: true})
+		assert.NotNil(t, err)
+	}
+}
+
+func TestWalkHardLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hard links are not detected on windows")
+	}
+	dir := createTestDir(t, 1, 2, 3)
+	assert.Nil(t, os.Link(filepath.Join(dir, "file-2.txt"), filepath.Join(dir, "Dir-0", "link-a.txt")))
+	assert.Nil(t, os.Link(filepath.Join(dir, "file-2.txt"), filepath.Join(dir, "Dir-1", "link-b.txt")))
+
+	for _, workers := range []int{1, 4} {
+		tr, err := walk(context.Background(), dir, []string{}, -1, workers)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 3*3+2, tr.Value.Count)
+		assert.Equal(t, int64(3*30), tr.Value.Size)
+		assert.Equal(t, int64(2*20), tr.Value.Linked)
+	}
+
+	// The lowest path is charged, independent of the order of the walk.
+	assert.Nil(t, os.Link(filepath.Join(dir, "Dir-1", "file-1.txt"), filepath.Join(dir, "Dir-0", "link-c.txt")))
+	seq, err := walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: 1, TopFiles: 5})
+	assert.Nil(t, err)
+	for i := 0; i < 10; i++ {
+		par, err := walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: 4, TopFiles: 5})
+		assert.Nil(t, err)
+
+		assert.Equal(t, int64(20), child(t, par, "Dir-0", "link-a.txt").Value.Size)
+		assert.Equal(t, int64(20), child(t, par, "Dir-1", "link-b.txt").Value.Linked)
+		assert.Equal(t, int64(20), child(t, par, "file-2.txt").Value.Linked)
+		assert.Equal(t, int64(10), child(t, par, "Dir-0", "link-c.txt").Value.Size)
+		assert.Equal(t, int64(10), child(t, par, "Dir-1", "file-1.txt").Value.Linked)
+		assert.Equal(t, int64(20), child(t, par, "Dir-1").Value.Extensions[".txt"].Size)
+		assert.Equal(t, "Dir-0/link-a.txt", par.Value.Largest[1].Path)
+		assert.Equal(t, "Dir-1/file-2.txt", par.Value.Largest[2].Path)
+
+		par.Value.ScanTime = seq.Value.ScanTime
+		assert.Equal(t, seq, par)
+	}
+}
+
+func TestWalkSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links require privileges on windows")
+	}
+	dir := createTestDir(t, 1, 2, 3)
+	assert.Nil(t, os.Symlink(filepath.Join(dir, "Dir-1"), filepath.Join(dir, "Dir-0", "link-dir")))
+	assert.Nil(t, os.Symlink(dir, filepath.Join(dir, "Dir-1", "link-cycle")))
+	assert.Nil(t, os.Symlink("../file-2.txt", filepath.Join(dir, "Dir-1", "link-file")))
+
+	for _, workers := range []int{1, 4} {
+		tr, err := walk(context.Background(), dir, []string{}, -1, workers)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 3*3+3, tr.Value.Count)
+		link := child(t, tr, "Dir-0", "link-dir").Value
+		assert.Equal(t, filepath.Join(dir, "Dir-1"), link.Link)
+		assert.False(t, link.IsDir)
+		assert.Equal(t, "../file-2.txt", child(t, tr, "Dir-1", "link-file").Value.Link)
+		assert.Equal(t, 3, tr.Value.Links)
+		assert.Equal(t, 1, child(t, tr, "Dir-0").Value.Links)
+		assert.Equal(t, 3*3, tr.Value.Files())
+
+		tr, err = walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: workers, FollowLinks: true})
+		assert.Nil(t, err)
+
+		// Files reached through followed links are charged once, to their path without links.
+		assert.Equal(t, int64(3*30+len(dir)), tr.Value.Size)
+		assert.Equal(t, int64(30+20+len(dir)+20), tr.Value.Linked)
+		link = child(t, tr, "Dir-0", "link-dir").Value
+		assert.True(t, link.IsDir)
+		assert.Equal(t, 5, link.Count)
+		assert.Equal(t, int64(0), link.Size)
+		assert.Equal(t, int64(30+20+len(dir)), link.Linked)
+		assert.Equal(t, int64(20), child(t, tr, "Dir-1", "file-2.txt").Value.Size)
+
+		cycle := child(t, tr, "Dir-0", "link-dir", "link-cycle").Value
+		assert.False(t, cycle.IsDir)
+		assert.Equal(t, dir, cycle.Link)
+
+		file := child(t, tr, "Dir-1", "link-file").Value
+		assert.Equal(t, "../file-2.txt", file.Link)
+		assert.Equal(t, int64(0), file.Size)
+		assert.Equal(t, int64(20), file.Linked)
+
+		// Files are not their own duplicates through links.
+		sets, err := FindDuplicates(context.Background(), dir, tr, 0)
+		assert.Nil(t, err)
+		for _, set := range sets {
+			for _, p := range set.Paths {
+				assert.NotContains(t, p, "link-")
+			}
+		}
+	}
+}
+
+func TestWalkIgnoreFiles(t *testing.T) {
+	dir := createTestDir(t, 1, 2, 3)
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("# comment\nDir-1/\n*-2.txt\n"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "Dir-0", ".dirstatignore"), []byte("!file-2.txt\r\nfile-0.txt \r\n"), 0644))
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, ".git"), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, ".git", "config"), []byte{}, 0644))
+
+	for _, workers := range []int{1, 4} {
+		tr, err := walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: workers, Ignore: IgnoreRespect})
+		assert.Nil(t, err)
+
+		assert.Equal(t, 3+3, tr.Value.Count)
+		assert.Equal(t, []string{"Dir-0", ".gitignore", "file-0.txt", "file-1.txt"}, names(tr))
+		assert.Equal(t, []string{".dirstatignore", "file-1.txt", "file-2.txt"}, names(child(t, tr, "Dir-0")))
+
+		tr, err = walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: workers, Ignore: IgnoreInvert})
+		assert.Nil(t, err)
+
+		assert.Equal(t, 1+1+3, tr.Value.Count)
+		assert.Equal(t, []string{"Dir-0", "Dir-1", "file-2.txt"}, names(tr))
+		assert.Equal(t, []string{"file-0.txt"}, names(child(t, tr, "Dir-0")))
+		assert.Equal(t, 3, child(t, tr, "Dir-1").Value.Count)
+	}
+}
+
+func TestWalkIgnoreAll(t *testing.T) {
+	dir := createTestDir(t, 1, 2, 3)
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "Dir-0", ".dirstatignore"), []byte("**\n"), 0644))
+
+	for _, workers := range []int{1, 4} {
+		tr, err := walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: workers, Ignore: IgnoreRespect})
+		assert.Nil(t, err)
+
+		assert.Equal(t, 3+3, tr.Value.Count)
+		assert.Equal(t, []string{}, names(child(t, tr, "Dir-0")))
+		assert.Equal(t, 3, child(t, tr, "Dir-1").Value.Count)
+	}
+}
+
+func TestWalkOwners(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("owners are not available on windows")
+	}
+	dir := createTestDir(t, 1, 2, 3)
+
+	info, err := os.Stat(dir)
+	assert.Nil(t, err)
+	owner := ownerNames{}.name(info)
+	assert.NotEmpty(t, owner)
+
+	tr, err := walk(context.Background(), dir, []string{}, 0, 1)
+	assert.Nil(t, err)
+
+	assert.Equal(t, owner, tr.Value.Owner)
+	assert.Equal(t, []string{owner}, maps.Keys(tr.Value.Owners))
+	assert.Equal(t, 3*3, tr.Value.Owners[owner].Count)
+	assert.Equal(t, int64(3*30), tr.Value.Owners[owner].Size)
+}
+
+func TestWalkCounts(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/file.txt":       &fstest.MapFile{Data: []byte("data")},
+		"a/empty":          &fstest.MapFile{Mode: fs.ModeDir},
+		"b/pipe":           &fstest.MapFile{Mode: fs.ModeNamedPipe},
+		"b/c/d/empty":      &fstest.MapFile{Mode: fs.ModeDir},
+		"b/c/d/e/file.txt": &fstest.MapFile{Data: []byte("data")},
+	}
+
+	for _, workers := range []int{1, 4} {
+		for _, depth := range []int{-1, 1} {
+			tr, err := walkFS(context.Background(), fsys, ".", Options{MaxDepth: depth, Workers: workers})
+			assert.Nil(t, err)
+
+			assert.Equal(t, 3, tr.Value.Count)
+			assert.Equal(t, 2, tr.Value.Files())
+			assert.Equal(t, 1, tr.Value.Special)
+			assert.Equal(t, 0, tr.Value.Links)
+			assert.Equal(t, 8, tr.Value.Dirs)
+			assert.Equal(t, 2, tr.Value.Empty)
+
+			b := child(t, tr, "b").Value
+			assert.Equal(t, 5, b.Dirs)
+			assert.Equal(t, 1, b.Empty)
+			assert.Equal(t, 1, b.Special)
+		}
+	}
+}
+
+func TestWalkTimeKind(t *testing.T) {
+	dir := createTestDir(t, 0, 0, 2)
+	accessed := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	modified := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Nil(t, os.Chtimes(filepath.Join(dir, "file-1.txt"), accessed, modified))
+
+	tr, err := walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, tree.TimeModified, tr.Value.TimeKind)
+	assert.True(t, modified.Equal(child(t, tr, "file-1.txt").Value.Time))
+	assert.NotNil(t, tr.Value.ScanTime)
+	assert.True(t, modified.Equal(tr.Value.OldestTime()))
+	assert.Equal(t, 1, tr.Value.Ages[0])
+	assert.Equal(t, 1, tr.Value.Ages[len(tree.AgeBins)-1]+tr.Value.Ages[len(tree.AgeBins)])
+	assert.True(t, modified.Equal(tr.Value.Extensions[".txt"].OldestTime()))
+
+	tr, err = walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: 1, Time: tree.TimeAccessed})
+	assert.Nil(t, err)
+	assert.Equal(t, tree.TimeAccessed, tr.Value.TimeKind)
+	assert.True(t, accessed.Equal(child(t, tr, "file-1.txt").Value.Time))
+
+	// Birth times fall back to the modification time where they are not available.
+	info, err := os.Lstat(filepath.Join(dir, "file-1.txt"))
+	assert.Nil(t, err)
+	born := fileTime(osFS{}, filepath.Join(dir, "file-1.txt"), info, tree.TimeBirth)
+	if runtime.GOOS != "windows" {
+		time.Sleep(10 * time.Millisecond)
+		assert.Nil(t, os.Symlink("file-1.txt", filepath.Join(dir, "link.txt")))
+	}
+	for _, workers := range []int{1, 4} {
+		tr, err := walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: workers, Time: tree.TimeBirth, FollowLinks: true})
+		assert.Nil(t, err)
+		assert.True(t, born.Equal(child(t, tr, "file-1.txt").Value.Time))
+		if runtime.GOOS != "windows" {
+			assert.True(t, born.Equal(child(t, tr, "link.txt").Value.Time))
+		}
+	}
+
+	// Baselines with another kind of timestamps are not reused.
+
+	baseline := NewBaseline(tr)
+	_, err = walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: 1, Baseline: baseline})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, baseline.Reused())
+
+	_, err = walkOpts(context.Background(), dir, Options{MaxDepth: -1, Workers: 1, Time: "xtime"})
+	assert.NotNil(t, err)
+}
+
+// names returns the names of the children of a tree
+func names(tr *tree.FileTree) []string {
+	result := []string{}
+	for _, c := range tr.Children {
+		result = append(result, c.Value.Name)
+	}
+	return result
+}
+
+// child selects a sub-tree by names. Names may contain slashes, like those of placeholders below the maximum depth
+func child(t *testing.T, tr *tree.FileTree, path ...string) *tree.FileTree {
+	for _, name := range path {
+		found := false
+		for _, c := range tr.Children {
+			if c.Value.Name == name {
+				tr, found = c, true
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("Path element '%s' not found in tree", name)
+		}
+	}
+	return tr
+}
+
+func benchmarkWalk(b *testing.B, workers int) {
+	dir := createTestDir(b, 4, 4, 20)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := walk(context.Background(), dir, []string{}, 2, workers)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWalkSequential(b *testing.B) {
+	benchmarkWalk(b, 1)
+}
+
+func BenchmarkWalkParallel4(b *testing.B) {
+	benchmarkWalk(b, 4)
+}
+
+func BenchmarkWalkParallel16(b *testing.B) {
+	benchmarkWalk(b, 16)
+}
+
+func TestWalkMaxScanDepth(t *testing.T) {
+	fsys := fstest.MapFS{
+		"file.txt":         &fstest.MapFile{Data: []byte("data")},
+		"a/file.txt":       &fstest.MapFile{Data: []byte("data")},
+		"a/b/file.txt":     &fstest.MapFile{Data: []byte("data")},
+		"a/b/c/d/file.txt": &fstest.MapFile{Data: []byte("data")},
+	}
+
+	for _, workers := range []int{1, 4} {
+		tr, err := walkFS(context.Background(), fsys, ".", Options{MaxDepth: -1, MaxScanDepth: 2, Workers: workers})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(8), tr.Value.Size)
+
+		b := child(t, child(t, tr, "a"), "b").Value
+		assert.Equal(t, tree.UnscannedDepth, b.Unscanned)
+		assert.Equal(t, int64(0), b.Size)
+		// Totals are lower bounds.
+		assert.True(t, tr.Value.Incomplete)
+		assert.True(t, child(t, tr, "a").Value.Incomplete)
+
+		tr, err = walkFS(context.Background(), fsys, ".", Options{MaxDepth: 1, MaxScanDepth: 2, Workers: workers})
+		assert.Nil(t, err)
+		assert.Equal(t, tree.UnscannedDepth, child(t, child(t, tr, "a"), "b").Value.Unscanned)
+
+		tr, err = walkFS(context.Background(), fsys, ".", Options{MaxDepth: -1, Workers: workers})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(16), tr.Value.Size)
+		assert.False(t, tr.Value.Incomplete)
+	}
+}
@@ -0,0 +1,50 @@
+package print
+
+import (
+	"time"
+
+	"github.com/mlange-42/dirstat/tree"
+)
+
+const (
+	// AgeNewest is for showing the age of the newest file in directories
+	AgeNewest string = "newest"
+	// AgeOldest is for showing the age of the oldest file in directories
+	AgeOldest string = "oldest"
+	// AgeMedian is for showing the approximate median age of files in directories
+	AgeMedian string = "median"
+)
+
+// entryTime returns the timestamp of a file entry selected by stat, one of the Age... constants.
+// Ref is the time of the analysis, for median ages.
+func entryTime(e *tree.FileEntry, stat string, ref time.Time) time.Time {
+	switch stat {
+	case AgeOldest:
+		return e.OldestTime()
+	case AgeMedian:
+		return e.MedianTime(ref)
+	default:
+		return e.Time
+	}
+}
+
+// extensionTime returns the timestamp of an extension entry selected by stat, one of the Age... constants.
+// Ref is the time of the analysis, for median ages.
+func extensionTime(e *tree.ExtensionEntry, stat string, ref time.Time) time.Time {
+	switch stat {
+	case AgeOldest:
+		return e.OldestTime()
+	case AgeMedian:
+		return e.MedianTime(ref)
+	default:
+		return e.Time
+	}
+}
+
+// scanTime returns the time of the analysis of a tree, or now for trees without it
+func scanTime(t *tree.FileTree, now time.Time) time.Time {
+	if t.Value.ScanTime == nil {
+		return now
+	}
+	return *t.Value.ScanTime
+}
@@ -8,6 +8,10 @@ var (
 	fileColor       = color.C256(15, false).Sprint
 	hiddenFileColor = color.S256(15, 238).Sprint
 	extensionColor  = color.C256(11, false).Sprint
+	linkColor       = color.C256(44, false).Sprint
+	annotationColor = color.C256(244, false).Sprint
+	growColor       = color.C256(203, false).Sprint
+	shrinkColor     = color.C256(77, false).Sprint
 )
 
 var defaultColors = []func(a ...interface{}) string{
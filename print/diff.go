@@ -0,0 +1,151 @@
+package print
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mlange-42/dirstat/tree"
+	"github.com/mlange-42/dirstat/util"
+)
+
+// DiffPrinter prints a diff tree in plain text format, in the style of FileTreePrinter.
+// Children are sorted by their absolute size delta.
+type DiffPrinter struct {
+	SizeMode string
+	OnlyDirs bool
+	files    FileTreePrinter
+}
+
+// NewDiffPrinter creates a new DiffPrinter
+func NewDiffPrinter(sizeMode string, indent int, onlyDirs bool) DiffPrinter {
+	return DiffPrinter{
+		SizeMode: sizeMode,
+		OnlyDirs: onlyDirs,
+		files:    NewFileTreePrinter(false, false, false, sizeMode, 1.0, indent, false, onlyDirs, 1.0),
+	}
+}
+
+// Print prints a DiffTree
+func (p DiffPrinter) Print(t *tree.DiffTree) string {
+	width := 0
+	t.Walk(func(n tree.Node[*tree.DiffEntry]) tree.WalkAction {
+		suffix, _ := changeSuffix(n.Tree.Value)
+		if w := strLen(n.Tree.Value.Name) + strLen(suffix) + n.Depth()*p.files.Indent; w > width {
+			width = w
+		}
+		return tree.Continue
+	}, nil)
+	p.files.printWidth = width + 1
+	if p.files.printWidth < 16 {
+		p.files.printWidth = 16
+	} else if p.files.printWidth > 64 {
+		p.files.printWidth = 64
+	}
+
+	view := viewTree(t, func(n tree.Node[*tree.DiffEntry]) bool {
+		return n.Tree.Value.IsDir || !p.OnlyDirs
+	}, p.sortChildren)
+	sb := strings.Builder{}
+	walkLines(view, p.files, &sb, func(n tree.Node[*tree.DiffEntry]) bool {
+		siblings := n.Parent().Children
+		return siblings[len(siblings)-1] == n.Tree
+	}, func(n tree.Node[*tree.DiffEntry]) {
+		p.printEntry(n.Tree.Value, &sb, n.Depth())
+	}, nil)
+	return sb.String()
+}
+
+// sortChildren sorts children by their absolute size delta, and then by their absolute count delta
+func (p DiffPrinter) sortChildren(children []*tree.DiffTree) []*tree.DiffTree {
+	sort.SliceStable(children, func(i, j int) bool {
+		a, b := children[i].Value, children[j].Value
+		if da, db := abs(p.sizeDelta(a)), abs(p.sizeDelta(b)); da != db {
+			return da > db
+		}
+		return abs(int64(a.CountDelta)) > abs(int64(b.CountDelta))
+	})
+	return children
+}
+
+// printEntry prints the line of an entry at depth, after its prefix
+func (p DiffPrinter) printEntry(e *tree.DiffEntry, sb *strings.Builder, depth int) {
+	suffix, suffixColored := changeSuffix(e)
+	fmt.Fprint(sb, p.files.nameColumn(e.Name, e.IsDir, false, suffix, suffixColored, depth))
+	if e.IsDir {
+		countStr := deltaColor(int64(e.CountDelta))(fmt.Sprintf(" %6s", formatDelta(int64(e.CountDelta), "")))
+		fmt.Fprintf(sb, " %s %s\n", p.sizeColumns(e), countStr)
+	} else {
+		fmt.Fprintf(sb, " %s\n", p.sizeColumns(e))
+	}
+}
+
+// sizeColumns formats and colors old size, new size and delta, for the sizes selected by SizeMode
+func (p DiffPrinter) sizeColumns(e *tree.DiffEntry) string {
+	sizeStr := diffColumns(e.OldSize, e.NewSize, e.SizeDelta)
+	diskStr := diffColumns(e.OldDisk, e.NewDisk, e.DiskDelta)
+	switch p.SizeMode {
+	case SizeDisk:
+		return diskStr
+	case SizeBoth:
+		return sizeStr + "  " + diskStr
+	default:
+		return sizeStr
+	}
+}
+
+// sizeDelta returns the size delta used for sorting.
+// This is the delta of the size on disk for SizeDisk, and of the apparent size otherwise.
+func (p DiffPrinter) sizeDelta(e *tree.DiffEntry) int64 {
+	if p.SizeMode == SizeDisk {
+		return e.DiskDelta
+	}
+	return e.SizeDelta
+}
+
+// diffColumns formats old size, new size and colored delta
+func diffColumns(before, after, delta int64) string {
+	return fmt.Sprintf("%7s → %7s %s", util.FormatUnits(before, "B"), util.FormatUnits(after, "B"),
+		deltaColor(delta)(fmt.Sprintf("%8s", formatDelta(delta, "B"))))
+}
+
+// formatDelta formats a signed delta with unit prefixes
+func formatDelta(delta int64, unit string) string {
+	switch {
+	case delta > 0:
+		return "+" + util.FormatUnits(delta, unit)
+	case delta < 0:
+		return "-" + util.FormatUnits(-delta, unit)
+	default:
+		return util.FormatUnits(0, unit)
+	}
+}
+
+// deltaColor returns the color for a delta, depending on its sign
+func deltaColor(delta int64) func(a ...interface{}) string {
+	switch {
+	case delta > 0:
+		return growColor
+	case delta < 0:
+		return shrinkColor
+	default:
+		return annotationColor
+	}
+}
+
+// changeSuffix returns the annotation of added and removed entries.
+// Returns the plain and the colored annotation.
+func changeSuffix(e *tree.DiffEntry) (string, string) {
+	if e.Change != tree.DiffAdded && e.Change != tree.DiffRemoved {
+		return "", ""
+	}
+	suffix := " [" + e.Change + "]"
+	return suffix, annotationColor(suffix)
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
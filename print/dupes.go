@@ -0,0 +1,47 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mlange-42/dirstat/filesys"
+	"github.com/mlange-42/dirstat/util"
+)
+
+// DuplicatePrinter prints sets of duplicate files as text table
+type DuplicatePrinter struct{}
+
+// Print prints sets of duplicate files, with a summary line
+func (p DuplicatePrinter) Print(sets []filesys.DuplicateSet) string {
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "%9s %6s %9s  %s\n", "Size", "Copies", "Wasted", "Paths")
+
+	files := 0
+	var wasted int64
+	for _, s := range sets {
+		files += len(s.Paths)
+		wasted += s.Wasted
+		for i, path := range s.Paths {
+			if i == 0 {
+				fmt.Fprintf(&sb, "%9s %6d %9s  %s\n", util.FormatUnitsSimple(s.Size, "B"), len(s.Paths), util.FormatUnitsSimple(s.Wasted, "B"), path)
+			} else {
+				fmt.Fprintf(&sb, "%27s %s\n", "", path)
+			}
+		}
+	}
+	fmt.Fprintf(&sb, "%d sets, %d files, %s wasted\n", len(sets), files, util.FormatUnitsSimple(wasted, "B"))
+	return sb.String()
+}
+
+// DuplicateJSONPrinter prints sets of duplicate files in JSON format
+type DuplicateJSONPrinter struct{}
+
+// Print prints sets of duplicate files
+func (p DuplicateJSONPrinter) Print(sets []filesys.DuplicateSet) string {
+	js, err := json.MarshalIndent(sets, "", "    ")
+	if err != nil {
+		panic(err)
+	}
+	return string(js[:])
+}
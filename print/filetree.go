@@ -27,8 +27,14 @@ const (
 // FileTreePrinter prints a file tree in plain text format
 type FileTreePrinter struct {
 	SortBy        string
+	SizeMode      string
 	Cutoff        float64
 	ByExtension   bool
+	ByOwner       bool
+	ByCategory    bool
+	AgeStat       string
+	ShowCounts    bool
+	TopFiles      int
 	Indent        int
 	PrintTime     bool
 	OnlyDirs      bool
@@ -39,15 +45,20 @@ type FileTreePrinter struct {
 	prefixLast    string
 	printWidth    int
 	currTime      time.Time
+	scanTime      time.Time
 	ageRange      minMax
 	countRange    minMax
 	sizeRange     minMax
+	diskRange     minMax
 }
 
 // NewFileTreePrinter creates a new FileTreePrinter
-func NewFileTreePrinter(byExt bool, cutoff float64, indent int, printTime bool, onlyDirs bool, colorExponent float64) FileTreePrinter {
+func NewFileTreePrinter(byExt bool, byOwner bool, byCategory bool, sizeMode string, cutoff float64, indent int, printTime bool, onlyDirs bool, colorExponent float64) FileTreePrinter {
 	return FileTreePrinter{
+		SizeMode:      sizeMode,
 		ByExtension:   byExt,
+		ByOwner:       byOwner,
+		ByCategory:    byCategory,
 		Cutoff:        cutoff,
 		Indent:        indent,
 		PrintTime:     printTime,
@@ -64,9 +75,10 @@ func NewFileTreePrinter(byExt bool, cutoff float64, indent int, printTime bool,
 
 // Print prints a FileTree
 func (p FileTreePrinter) Print(t *tree.FileTree) string {
+	p.scanTime = scanTime(t, p.currTime)
 	p.calcRanges(t)
 
-	p.printWidth = p.maxWidth(t, 0, p.ByExtension) + 1
+	p.printWidth = p.maxWidth(t, p.grouped()) + 1
 	if p.printWidth < 16 {
 		p.printWidth = 16
 	} else if p.printWidth > 64 {
@@ -74,94 +86,204 @@ func (p FileTreePrinter) Print(t *tree.FileTree) string {
 	}
 
 	sb := strings.Builder{}
-	p.print(t, &sb, 0, false, "")
+	p.print(p.view(t), &sb)
+	if p.TopFiles > 0 && len(t.Value.Largest) > 0 {
+		p.printLargest(t.Value.Largest, &sb)
+	}
 	return sb.String()
 }
 
-func (p FileTreePrinter) print(t *tree.FileTree, sb *strings.Builder, depth int, last bool, prefix string) {
-	pref := prefix
+// view returns a tree of the entries to print, sharing the entries of t.
+// Children of each directory are filtered and sorted, and those cut off are combined into a single entry.
+func (p FileTreePrinter) view(t *tree.FileTree) *tree.FileTree {
+	return viewTree(t, func(n tree.Node[*tree.FileEntry]) bool {
+		return n.Tree.Value.IsDir || !(p.OnlyDirs || p.grouped())
+	}, p.sortChildren)
+}
 
-	if depth > 0 {
-		pref = prefix + p.createPrefix(last)
+// viewTree returns a tree of the nodes of t for which keep returns true, sharing the values of t.
+// The root is always kept. Children of each node are ordered by sort.
+func viewTree[T any](t *tree.Tree[T], keep func(n tree.Node[T]) bool, sort func(children []*tree.Tree[T]) []*tree.Tree[T]) *tree.Tree[T] {
+	nodes := map[*tree.Tree[T]]*tree.Tree[T]{}
+	t.Walk(func(n tree.Node[T]) tree.WalkAction {
+		parent := n.Parent()
+		if parent != nil && !keep(n) {
+			return tree.SkipChildren
+		}
+		v := tree.New(n.Tree.Value)
+		nodes[n.Tree] = v
+		if parent != nil {
+			nodes[parent].AddTree(v)
+		}
+		return tree.Continue
+	}, func(n tree.Node[T]) tree.WalkAction {
+		if v, ok := nodes[n.Tree]; ok {
+			v.Children = sort(v.Children)
+		}
+		return tree.Continue
+	})
+	return nodes[t]
+}
+
+// sortChildren sorts children by SortBy, and combines those cut off by Cutoff into a single entry
+func (p FileTreePrinter) sortChildren(children []*tree.FileTree) []*tree.FileTree {
+	switch p.SortBy {
+	case BySize:
+		sorter := FileEntrySorter{children, func(t *tree.FileTree) float64 { return float64(entrySize(t.Value, p.SizeMode)) }}
+		return sorter.Sort(p.Cutoff)
+	case ByCount:
+		sorter := FileEntrySorter{children, func(t *tree.FileTree) float64 { return float64(t.Value.Count) }}
+		return sorter.Sort(p.Cutoff)
+	case ByAge:
+		sorter := FileEntrySorter{children, func(t *tree.FileTree) float64 { return -float64(p.entryTime(t.Value).Unix()) }}
+		return sorter.Sort(1.0)
+	case ByName:
+		return children
+	default:
+		panic(fmt.Errorf("Unknown sort field '%s'", p.SortBy))
 	}
-	pad := strings.Repeat(".", int(math.Max(float64(p.printWidth-depth*p.Indent-strLen(t.Value.Name)), 0)))
-	fmt.Fprint(sb, pref)
-	if t.Value.IsDir {
-		sizeStr := fmt.Sprintf(" %6s ", util.FormatUnits(t.Value.Size, "B"))
-		countStr := fmt.Sprintf(" %5s ", util.FormatUnits(int64(t.Value.Count), ""))
+}
 
-		sizeStr = p.sizeRange.Interpolate(float64(t.Value.Size), p.ColorExponent)(sizeStr)
-		countStr = p.countRange.Interpolate(float64(t.Value.Count), p.ColorExponent)(countStr)
+// print prints the view of a tree, with the groups of each directory after its children
+func (p FileTreePrinter) print(t *tree.FileTree, sb *strings.Builder) {
+	walkLines(t, p, sb, p.isLast, func(n tree.Node[*tree.FileEntry]) {
+		p.printEntry(n.Tree.Value, sb, n.Depth())
+	}, func(n tree.Node[*tree.FileEntry], prefix string) {
+		if p.grouped() && n.Tree.Value.IsDir {
+			p.printExtensions(groups(n.Tree.Value, p.ByOwner, p.ByCategory), n.Tree.Value.Incomplete, sb, n.Depth()+1, prefix)
+		}
+	})
+}
 
-		nameColor := directoryColor
-		if depth > 0 && strings.HasPrefix(t.Value.Name, ".") {
-			nameColor = hiddenDirColor
+// walkLines walks a tree to print it, with one line per node.
+// Before each node, the prefix of its line is printed, and line is called to print the rest of it.
+// Post is called after the children of each node, with the prefix for further lines below the node.
+// Last reports whether a node is printed last among its siblings.
+func walkLines[T any](t *tree.Tree[T], p FileTreePrinter, sb *strings.Builder,
+	last func(n tree.Node[T]) bool, line func(n tree.Node[T]), post func(n tree.Node[T], prefix string)) {
+	// Prefixes of the children of the nodes on the current path, by depth
+	prefixes := []string{""}
+	t.Walk(func(n tree.Node[T]) tree.WalkAction {
+		depth := n.Depth()
+		prefix := ""
+		if depth > 0 {
+			isLast := last(n)
+			prefix = prefixes[depth-1] + p.createPrefix(isLast)
+			prefixes = append(prefixes[:depth], prefixes[depth-1]+p.createPrefixEmpty(isLast))
 		}
-		fmt.Fprintf(sb, "%s %s %s %s", nameColor(t.Value.Name+"/"), pad, sizeStr, countStr)
-	} else {
-		sizeStr := fmt.Sprintf(" %6s ", util.FormatUnits(t.Value.Size, "B"))
+		fmt.Fprint(sb, prefix)
+		line(n)
+		return tree.Continue
+	}, func(n tree.Node[T]) tree.WalkAction {
+		if post != nil {
+			post(n, prefixes[n.Depth()])
+		}
+		return tree.Continue
+	})
+}
+
+// isLast checks whether a node is printed last among its siblings, i.e. it is the last child,
+// and no groups are printed after it
+func (p FileTreePrinter) isLast(n tree.Node[*tree.FileEntry]) bool {
+	siblings := n.Parent().Children
+	if siblings[len(siblings)-1] != n.Tree {
+		return false
+	}
+	return !p.grouped() || len(groups(n.Parent().Value, p.ByOwner, p.ByCategory)) == 0
+}
 
-		sizeStr = p.sizeRange.Interpolate(float64(t.Value.Size), p.ColorExponent)(sizeStr)
+// printEntry prints the line of an entry at depth, after its prefix
+func (p FileTreePrinter) printEntry(e *tree.FileEntry, sb *strings.Builder, depth int) {
+	suffix, suffixColored := nameSuffix(e)
+	fmt.Fprint(sb, p.nameColumn(e.Name, e.IsDir, e.Folded > 0, suffix, suffixColored, depth))
+	if e.IsDir {
+		var sizeStr, countStr string
+		if len(e.Unscanned) > 0 {
+			sizeStr = p.unknownColumns()
+			countStr = fmt.Sprintf(" %5s ", "?")
+		} else {
+			bound := boundPrefix(e.Incomplete)
+			sizeStr = p.sizeColumns(e.Size, e.Disk, bound)
+			countStr = fmt.Sprintf("%s%5s ", bound, util.FormatUnits(int64(e.Count), ""))
 
-		nameColor := fileColor
-		if depth > 0 && strings.HasPrefix(t.Value.Name, ".") {
-			nameColor = hiddenFileColor
+			countStr = p.countRange.Interpolate(float64(e.Count), p.ColorExponent)(countStr)
 		}
-		fmt.Fprintf(sb, "%s .%s %s        ", nameColor(t.Value.Name), pad, sizeStr)
+		fmt.Fprintf(sb, " %s %s%s", sizeStr, countStr, p.countsColumns(e))
+	} else {
+		sizeStr := p.sizeColumns(e.Size, e.Disk, " ")
+		fmt.Fprintf(sb, " %s        %s", sizeStr, p.countsColumns(nil))
 	}
 
 	if p.PrintTime {
-		val := fmt.Sprintf(" %11s ", util.FormatDuration(t.Value.Time, p.currTime))
-		fmt.Fprintf(sb, " %s", p.ageRange.Interpolate(float64(p.currTime.Unix()-t.Value.Time.Unix()), p.ColorExponent)(val))
+		tm := p.entryTime(e)
+		val := fmt.Sprintf(" %11s ", util.FormatDuration(tm, p.currTime))
+		fmt.Fprintf(sb, " %s", p.ageRange.Interpolate(float64(p.currTime.Unix()-tm.Unix()), p.ColorExponent)(val))
 	}
 	fmt.Fprint(sb, "\n")
+}
 
-	if depth > 0 {
-		pref = prefix + p.createPrefixEmpty(last)
+// nameColumn formats the name of an entry at depth, followed by its annotations suffix and dots up to the print width.
+// Directories are marked by a slash, except for folded entries like those cut off, which are no real directories.
+// Hidden entries below the root are dimmed.
+func (p FileTreePrinter) nameColumn(name string, isDir bool, folded bool, suffix string, suffixColored string, depth int) string {
+	pad := strings.Repeat(".", int(math.Max(float64(p.printWidth-depth*p.Indent-strLen(name)-strLen(suffix)), 0)))
+	hidden := depth > 0 && strings.HasPrefix(name, ".")
+	switch {
+	case folded:
+		return annotationColor(name) + suffixColored + " ." + pad
+	case isDir && hidden:
+		return hiddenDirColor(name+"/") + suffixColored + " " + pad
+	case isDir:
+		return directoryColor(name+"/") + suffixColored + " " + pad
+	case hidden:
+		return hiddenFileColor(name) + suffixColored + " ." + pad
+	default:
+		return fileColor(name) + suffixColored + " ." + pad
 	}
+}
 
-	var children []*tree.FileTree
-	for _, child := range t.Children {
-		if child.Value.IsDir || !(p.OnlyDirs || p.ByExtension) {
-			children = append(children, child)
-		}
+// printLargest prints up to TopFiles of the largest files, with their paths
+func (p FileTreePrinter) printLargest(files []tree.LargeFile, sb *strings.Builder) {
+	if len(files) > p.TopFiles {
+		files = files[:p.TopFiles]
 	}
-	switch p.SortBy {
-	case BySize:
-		sorter := FileEntrySorter{children, func(t *tree.FileTree) float64 { return float64(t.Value.Size) }}
-		children = sorter.Sort(p.Cutoff)
-	case ByCount:
-		sorter := FileEntrySorter{children, func(t *tree.FileTree) float64 { return float64(t.Value.Count) }}
-		children = sorter.Sort(p.Cutoff)
-	case ByAge:
-		sorter := FileEntrySorter{children, func(t *tree.FileTree) float64 { return -float64(t.Value.Time.Unix()) }}
-		children = sorter.Sort(1.0)
-	case ByName:
-	default:
-		panic(fmt.Errorf("Unknown sort field '%s'", p.SortBy))
+	fmt.Fprint(sb, "\nLargest files:\n")
+	for _, f := range files {
+		fmt.Fprintf(sb, "%s", p.sizeColumns(f.Size, f.Disk, " "))
+		if p.PrintTime {
+			val := fmt.Sprintf(" %11s ", util.FormatDuration(f.Time, p.currTime))
+			fmt.Fprintf(sb, " %s", p.ageRange.Interpolate(float64(p.currTime.Unix()-f.Time.Unix()), p.ColorExponent)(val))
+		}
+		fmt.Fprintf(sb, "  %s\n", fileColor(f.Path))
 	}
+}
 
-	for i, child := range children {
-		last := i == len(children)-1 && (!p.ByExtension || len(t.Value.Extensions) == 0)
-		p.print(child, sb, depth+1, last, pref)
-	}
+// entryTime returns the timestamp of a file entry selected by AgeStat
+func (p FileTreePrinter) entryTime(e *tree.FileEntry) time.Time {
+	return entryTime(e, p.AgeStat, p.scanTime)
+}
 
-	if p.ByExtension && t.Value.IsDir {
-		p.printExtensions(t.Value.Extensions, sb, depth+1, pref)
-	}
+// extensionTime returns the timestamp of an extension entry selected by AgeStat
+func (p FileTreePrinter) extensionTime(e *tree.ExtensionEntry) time.Time {
+	return extensionTime(e, p.AgeStat, p.scanTime)
 }
 
-func (p FileTreePrinter) printExtensions(ext map[string]*tree.ExtensionEntry, sb *strings.Builder, depth int, prefix string) {
+// grouped checks whether directory content is shown by extension or owner instead of individual files
+func (p FileTreePrinter) grouped() bool {
+	return p.ByExtension || p.ByOwner || p.ByCategory
+}
+
+func (p FileTreePrinter) printExtensions(ext map[string]*tree.ExtensionEntry, incomplete bool, sb *strings.Builder, depth int, prefix string) {
 	values := maps.Values(ext)
 	switch p.SortBy {
 	case BySize:
-		sorter := ExtensionEntrySorter{values, func(e *tree.ExtensionEntry) float64 { return float64(e.Size) }}
+		sorter := ExtensionEntrySorter{values, func(e *tree.ExtensionEntry) float64 { return float64(extensionSize(e, p.SizeMode)) }}
 		values = sorter.Sort(p.Cutoff)
 	case ByCount:
 		sorter := ExtensionEntrySorter{values, func(e *tree.ExtensionEntry) float64 { return float64(e.Count) }}
 		values = sorter.Sort(p.Cutoff)
 	case ByAge:
-		sorter := ExtensionEntrySorter{values, func(e *tree.ExtensionEntry) float64 { return -float64(e.Time.Unix()) }}
+		sorter := ExtensionEntrySorter{values, func(e *tree.ExtensionEntry) float64 { return -float64(p.extensionTime(e).Unix()) }}
 		values = sorter.Sort(1.0)
 	case ByName:
 		sort.Slice(values, func(i, j int) bool {
@@ -182,73 +304,79 @@ func (p FileTreePrinter) printExtensions(ext map[string]*tree.ExtensionEntry, sb
 			fmt.Fprint(sb, pref)
 		}
 
-		sizeStr := fmt.Sprintf(" %6s ", util.FormatUnits(info.Size, "B"))
-		countStr := fmt.Sprintf(" %5s ", util.FormatUnits(int64(info.Count), ""))
+		bound := boundPrefix(incomplete)
+		sizeStr := p.sizeColumns(info.Size, info.Disk, bound)
+		countStr := fmt.Sprintf("%s%5s ", bound, util.FormatUnits(int64(info.Count), ""))
 
-		sizeStr = p.sizeRange.Interpolate(float64(info.Size), p.ColorExponent)(sizeStr)
 		countStr = p.countRange.Interpolate(float64(info.Count), p.ColorExponent)(countStr)
 		fmt.Fprintf(
 			sb,
-			"%s .%s %s %s",
+			"%s .%s %s %s%s",
 			extensionColor(info.Name),
 			pad,
 			sizeStr,
 			countStr,
+			p.countsColumns(nil),
 		)
 
 		if p.PrintTime {
-			val := fmt.Sprintf(" %11s ", util.FormatDuration(info.Time, p.currTime))
-			fmt.Fprintf(sb, " %s", p.ageRange.Interpolate(float64(p.currTime.Unix()-info.Time.Unix()), p.ColorExponent)(val))
+			tm := p.extensionTime(info)
+			val := fmt.Sprintf(" %11s ", util.FormatDuration(tm, p.currTime))
+			fmt.Fprintf(sb, " %s", p.ageRange.Interpolate(float64(p.currTime.Unix()-tm.Unix()), p.ColorExponent)(val))
 		}
 		fmt.Fprint(sb, "\n")
 
 	}
 }
 
-func (p FileTreePrinter) maxWidth(t *tree.FileTree, depth int, extensions bool) int {
-	max := strLen(t.Value.Name) + depth*p.Indent
-	if extensions && t.Value.IsDir {
-		for name := range t.Value.Extensions {
-			m := strLen(name) + (depth+1)*p.Indent
-			if m > max {
-				max = m
-			}
+func (p FileTreePrinter) maxWidth(t *tree.FileTree, extensions bool) int {
+	max := 0
+	t.Walk(func(n tree.Node[*tree.FileEntry]) tree.WalkAction {
+		depth := n.Depth()
+		suffix, _ := nameSuffix(n.Tree.Value)
+		if m := strLen(n.Tree.Value.Name) + strLen(suffix) + depth*p.Indent; m > max {
+			max = m
 		}
-	}
-	for _, c := range t.Children {
-		v := p.maxWidth(c, depth+1, extensions)
-		if v > max {
-			max = v
+		if extensions && n.Tree.Value.IsDir {
+			for name := range groups(n.Tree.Value, p.ByOwner, p.ByCategory) {
+				if m := strLen(name) + (depth+1)*p.Indent; m > max {
+					max = m
+				}
+			}
 		}
-	}
+		return tree.Continue
+	}, nil)
 	return max
 }
 
 func (p *FileTreePrinter) calcRanges(t *tree.FileTree) {
-	p.calcAgeRange(t, p.ByExtension)
-	p.calcSizeRange(t, p.ByExtension)
-	p.calcCountRange(t, p.ByExtension)
+	p.calcAgeRange(t, p.grouped())
+	p.calcSizeRange(t, p.grouped())
+	p.calcDiskRange(t, p.grouped())
+	p.calcCountRange(t, p.grouped())
 }
 
 func (p *FileTreePrinter) calcAgeRange(t *tree.FileTree, extensions bool) {
 	unix := p.currTime.Unix()
-	p.ageRange.min, p.ageRange.max, _ = p.calcRange(t, extensions, true,
+	p.ageRange.min, p.ageRange.max, _ = p.calcRange(t, extensions,
 		func(e *tree.FileEntry) (float64, bool) {
-			if e.Time.IsZero() {
+			tm := p.entryTime(e)
+			if tm.IsZero() {
 				return 1, false
 			}
-			return math.Max(1, float64(unix-e.Time.Unix())), true
+			return math.Max(1, float64(unix-tm.Unix())), true
 		},
 		func(e *tree.ExtensionEntry) (float64, bool) {
-			if e.Time.IsZero() {
+			tm := p.extensionTime(e)
+			if tm.IsZero() {
 				return 1, false
 			}
-			return math.Max(1, float64(unix-e.Time.Unix())), true
+			return math.Max(1, float64(unix-tm.Unix())), true
 		})
 }
 
 func (p *FileTreePrinter) calcSizeRange(t *tree.FileTree, extensions bool) {
-	p.sizeRange.min, p.sizeRange.max, _ = p.calcRange(t, extensions, true,
+	p.sizeRange.min, p.sizeRange.max, _ = p.calcRange(t, extensions,
 		func(e *tree.FileEntry) (float64, bool) {
 			return float64(e.Size), true
 		},
@@ -257,8 +385,18 @@ func (p *FileTreePrinter) calcSizeRange(t *tree.FileTree, extensions bool) {
 		})
 }
 
+func (p *FileTreePrinter) calcDiskRange(t *tree.FileTree, extensions bool) {
+	p.diskRange.min, p.diskRange.max, _ = p.calcRange(t, extensions,
+		func(e *tree.FileEntry) (float64, bool) {
+			return float64(e.Disk), true
+		},
+		func(e *tree.ExtensionEntry) (float64, bool) {
+			return float64(e.Disk), true
+		})
+}
+
 func (p *FileTreePrinter) calcCountRange(t *tree.FileTree, extensions bool) {
-	p.countRange.min, p.countRange.max, _ = p.calcRange(t, extensions, true,
+	p.countRange.min, p.countRange.max, _ = p.calcRange(t, extensions,
 		func(e *tree.FileEntry) (float64, bool) {
 			return float64(e.Count), true
 		},
@@ -267,54 +405,77 @@ func (p *FileTreePrinter) calcCountRange(t *tree.FileTree, extensions bool) {
 		})
 }
 
-func (p FileTreePrinter) calcRange(t *tree.FileTree, extensions bool, isRoot bool,
+// calcRange returns the range of the values of all entries except the root, and of their groups if extensions is set.
+// Entries for which fileFn or extFn return false are not considered. Returns false if no value was found.
+func (p FileTreePrinter) calcRange(t *tree.FileTree, extensions bool,
 	fileFn func(*tree.FileEntry) (value float64, on bool),
 	extFn func(*tree.ExtensionEntry) (value float64, on bool)) (min float64, max float64, isOk bool) {
 
-	isOk = false
-
-	if isRoot {
-		min = math.MaxFloat64
-		max = -math.MaxFloat64
+	min = math.MaxFloat64
+	max = -math.MaxFloat64
+	add := func(v float64) {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
 		isOk = true
-	} else {
-		if v, ok := fileFn(t.Value); ok {
-			min = v
-			max = v
-			isOk = true
-		} else {
-			min = math.MaxFloat64
-			max = -math.MaxFloat64
-		}
 	}
-
-	if extensions && t.Value.IsDir {
-		for _, ext := range t.Value.Extensions {
-			if v, ok := extFn(ext); ok {
-				if v < min {
-					min = v
-				}
-				if v > max {
-					max = v
+	t.Walk(func(n tree.Node[*tree.FileEntry]) tree.WalkAction {
+		if n.Depth() > 0 {
+			if v, ok := fileFn(n.Tree.Value); ok {
+				add(v)
+			}
+		}
+		if extensions && n.Tree.Value.IsDir {
+			for _, ext := range groups(n.Tree.Value, p.ByOwner, p.ByCategory) {
+				if v, ok := extFn(ext); ok {
+					add(v)
 				}
-				isOk = true
 			}
 		}
+		return tree.Continue
+	}, nil)
+	return
+}
+
+// sizeColumns formats and colors the size columns selected by SizeMode
+func (p FileTreePrinter) sizeColumns(size, disk int64, bound string) string {
+	sizeStr := fmt.Sprintf("%s%6s ", bound, util.FormatUnits(size, "B"))
+	diskStr := fmt.Sprintf("%s%6s ", bound, util.FormatUnits(disk, "B"))
+
+	sizeStr = p.sizeRange.Interpolate(float64(size), p.ColorExponent)(sizeStr)
+	diskStr = p.diskRange.Interpolate(float64(disk), p.ColorExponent)(diskStr)
+
+	switch p.SizeMode {
+	case SizeDisk:
+		return diskStr
+	case SizeBoth:
+		return sizeStr + " " + diskStr
+	default:
+		return sizeStr
 	}
+}
 
-	for _, c := range t.Children {
-		if mn, mx, ok := p.calcRange(c, extensions, false, fileFn, extFn); ok {
-			if mn < min {
-				min = mn
-			}
-			if mx > max {
-				max = mx
-			}
-			isOk = true
-		}
+// countsColumns formats the numbers of directories, empty directories, links and special files,
+// if ShowCounts is set. Returns blank columns for nil entries and directories that were not scanned
+func (p FileTreePrinter) countsColumns(e *tree.FileEntry) string {
+	if !p.ShowCounts {
+		return ""
 	}
+	if e == nil || len(e.Unscanned) > 0 {
+		return strings.Repeat(" ", 40)
+	}
+	return fmt.Sprintf(" %5s dir %5s emp %5s lnk %5s spc",
+		util.FormatUnitsSimple(int64(e.Dirs), ""), util.FormatUnitsSimple(int64(e.Empty), ""),
+		util.FormatUnitsSimple(int64(e.Links), ""), util.FormatUnitsSimple(int64(e.Special), ""))
+}
 
-	return
+// unknownColumns formats the size columns selected by SizeMode
+// for directories that were not scanned
+func (p FileTreePrinter) unknownColumns() string {
+	unknown := fmt.Sprintf(" %6s ", "?")
+	if p.SizeMode == SizeBoth {
+		return unknown + " " + unknown
+	}
+	return unknown
 }
 
 func (p FileTreePrinter) createPrefix(last bool) string {
@@ -365,7 +526,8 @@ func (p FileEntrySorter) Sort(cutoff float64) []*tree.FileTree {
 	for _, e := range p.Slice {
 		value := p.Getter(e)
 		if isCut {
-			remainder.Value.Add(e.Value.Size, e.Value.Count, e.Value.Time)
+			remainder.Value.Add(e.Value.Size, e.Value.Disk, e.Value.Count, e.Value.Time)
+			remainder.Value.AddCounts(e.Value)
 			skipped++
 		} else {
 			result = append(result, e)
@@ -378,6 +540,7 @@ func (p FileEntrySorter) Sort(cutoff float64) []*tree.FileTree {
 
 	if skipped > 0 {
 		remainder.Value.Name = fmt.Sprintf("<skipped %d>", skipped)
+		remainder.Value.Folded = skipped
 		result = append(result, remainder)
 	}
 
@@ -418,7 +581,7 @@ func (p ExtensionEntrySorter) Sort(cutoff float64) []*tree.ExtensionEntry {
 	for _, e := range p.Slice {
 		value := p.Getter(e)
 		if isCut {
-			remainder.Add(e.Size, e.Count, e.Time)
+			remainder.Add(e.Size, e.Disk, e.Count, e.Time)
 			skipped++
 		} else {
 			result = append(result, e)
@@ -459,6 +622,38 @@ func (r minMax) Interpolate(value float64, exponent float64) func(a ...interface
 	return defaultColors[index]
 }
 
+// boundPrefix returns the prefix for numbers that are only a lower bound,
+// due to an incomplete scan.
+func boundPrefix(incomplete bool) string {
+	if incomplete {
+		return "≥"
+	}
+	return " "
+}
+
+// nameSuffix returns the annotations printed after an entry's name,
+// like " -> target" for symbolic links.
+// Returns the plain and the colored annotations.
+func nameSuffix(e *tree.FileEntry) (string, string) {
+	plain, colored := "", ""
+	if len(e.Link) > 0 {
+		link := " -> " + e.Link
+		plain += link
+		colored += linkColor(link)
+	}
+	if len(e.Archive) > 0 {
+		format := " [" + e.Archive + "]"
+		plain += format
+		colored += annotationColor(format)
+	}
+	if len(e.Unscanned) > 0 {
+		reason := " [" + e.Unscanned + "]"
+		plain += reason
+		colored += annotationColor(reason)
+	}
+	return plain, colored
+}
+
 func strLen(str string) int {
 	return utf8.RuneCountInString(str)
 }
@@ -0,0 +1,106 @@
+package print
+
+import (
+	"fmt"
+
+	"github.com/mlange-42/dirstat/tree"
+	"github.com/mlange-42/dirstat/util"
+)
+
+const (
+	// SizeApparent is for showing the apparent size of files
+	SizeApparent string = "apparent"
+	// SizeDisk is for showing the size allocated on disk
+	SizeDisk string = "disk"
+	// SizeBoth is for showing apparent size and size on disk side by side
+	SizeBoth string = "both"
+)
+
+// entrySize returns the size of a file entry used for sorting and scaling.
+// This is the size on disk for SizeDisk, and the apparent size otherwise.
+func entrySize(e *tree.FileEntry, mode string) int64 {
+	if mode == SizeDisk {
+		return e.Disk
+	}
+	return e.Size
+}
+
+// extensionSize returns the size of an extension entry used for sorting and scaling.
+// This is the size on disk for SizeDisk, and the apparent size otherwise.
+func extensionSize(e *tree.ExtensionEntry, mode string) int64 {
+	if mode == SizeDisk {
+		return e.Disk
+	}
+	return e.Size
+}
+
+const (
+	// CountFiles is for counting files, links and special files, i.e. all entries except directories
+	CountFiles string = "files"
+	// CountDirs is for counting directories
+	CountDirs string = "dirs"
+	// CountInodes is for counting all entries, including directories
+	CountInodes string = "inodes"
+	// CountEmpty is for counting empty directories
+	CountEmpty string = "empty"
+	// CountLinks is for counting symbolic links
+	CountLinks string = "links"
+	// CountSpecial is for counting special files, like named pipes, sockets and devices
+	CountSpecial string = "special"
+)
+
+// CountModes are all supported count modes
+var CountModes = []string{CountFiles, CountDirs, CountInodes, CountEmpty, CountLinks, CountSpecial}
+
+// entryCount returns the count of a file entry selected by mode, one of the Count... constants
+func entryCount(e *tree.FileEntry, mode string) int {
+	switch mode {
+	case CountDirs:
+		return e.Dirs
+	case CountInodes:
+		return e.Count + e.Dirs
+	case CountEmpty:
+		return e.Empty
+	case CountLinks:
+		return e.Links
+	case CountSpecial:
+		return e.Special
+	default:
+		return e.Count
+	}
+}
+
+// extensionCount returns the count of an extension entry selected by mode, one of the Count... constants.
+// Extension entries contain only files, so counts of directories are zero
+func extensionCount(e *tree.ExtensionEntry, mode string) int {
+	switch mode {
+	case CountFiles, CountInodes, "":
+		return e.Count
+	default:
+		return 0
+	}
+}
+
+// groups returns the entries directory content is grouped by,
+// which are owners if byOwner is set, categories if byCategory is set, and extensions otherwise
+func groups(e *tree.FileEntry, byOwner bool, byCategory bool) map[string]*tree.ExtensionEntry {
+	if byOwner {
+		return e.Owners
+	}
+	if byCategory {
+		return e.Categories
+	}
+	return e.Extensions
+}
+
+// formatSizes formats the sizes selected by mode, without extra padding
+func formatSizes(size, disk int64, mode string, bound string) string {
+	switch mode {
+	case SizeDisk:
+		return bound + util.FormatUnitsSimple(disk, "B")
+	case SizeBoth:
+		return fmt.Sprintf("%s%s (%s%s disk)", bound, util.FormatUnitsSimple(size, "B"), bound, util.FormatUnitsSimple(disk, "B"))
+	default:
+		return bound + util.FormatUnitsSimple(size, "B")
+	}
+}
@@ -13,60 +13,93 @@ import (
 // TreemapPrinter prints a tree in treemap CSV format
 type TreemapPrinter struct {
 	ByExtension bool
+	ByOwner     bool
+	ByCategory  bool
 	ByCount     bool
 	HeatAge     bool
 	OnlyDirs    bool
+	SizeMode    string
+	AgeStat     string
+	CountMode   string
 	currTime    time.Time
+	scanTime    time.Time
 }
 
 // NewTreemapPrinter creates a new TreemapPrinter
-func NewTreemapPrinter(byExtension, byCount, heatAge, onlyDirs bool) TreemapPrinter {
+func NewTreemapPrinter(byExtension, byOwner, byCategory, byCount, heatAge, onlyDirs bool, sizeMode string) TreemapPrinter {
 	return TreemapPrinter{
 		ByExtension: byExtension,
+		ByOwner:     byOwner,
+		ByCategory:  byCategory,
 		ByCount:     byCount,
 		HeatAge:     heatAge,
 		OnlyDirs:    onlyDirs,
+		SizeMode:    sizeMode,
 		currTime:    time.Now(),
 	}
 }
 
 // Print prints a FileTree
 func (p TreemapPrinter) Print(t *tree.FileTree) string {
+	p.scanTime = scanTime(t, p.currTime)
+	grouped := p.ByExtension || p.ByOwner || p.ByCategory
 	sb := strings.Builder{}
-	p.print(t, &sb, "")
+	// Paths of the nodes on the current path, by depth
+	paths := []string{}
+	t.Walk(func(n tree.Node[*tree.FileEntry]) tree.WalkAction {
+		depth := n.Depth()
+		if depth > 0 && !n.Tree.Value.IsDir && (grouped || p.OnlyDirs) {
+			return tree.SkipChildren
+		}
+		parent := ""
+		if depth > 0 {
+			parent = paths[depth-1]
+		}
+		paths = append(paths[:depth], p.print(n.Tree.Value, &sb, parent))
+		return tree.Continue
+	}, nil)
 	return sb.String()
 }
 
-func (p TreemapPrinter) print(t *tree.FileTree, sb *strings.Builder, path string) {
+// print prints the line of an entry and of its groups, with parent being the path of its parent.
+// Returns the path of the entry
+func (p TreemapPrinter) print(e *tree.FileEntry, sb *strings.Builder, parent string) string {
 	var sizeCount string
 
-	if t.Value.IsDir {
-		sizeCount = fmt.Sprintf("%s | %s",
-			util.FormatUnitsSimple(t.Value.Size, "B"), util.FormatUnitsSimple(int64(t.Value.Count), ""),
+	bound := ""
+	if e.Incomplete {
+		bound = "≥"
+	}
+
+	if len(e.Unscanned) > 0 {
+		sizeCount = fmt.Sprintf("? | ? | %s", e.Unscanned)
+	} else if e.IsDir {
+		sizeCount = fmt.Sprintf("%s | %s%s",
+			formatSizes(e.Size, e.Disk, p.SizeMode, bound), bound, util.FormatUnitsSimple(int64(e.Count), ""),
 		)
 	} else {
-		sizeCount = fmt.Sprintf("%s", util.FormatUnitsSimple(t.Value.Size, "B"))
+		sizeCount = formatSizes(e.Size, e.Disk, p.SizeMode, "")
 	}
 
 	dirSuffix := ""
-	if t.Value.IsDir {
+	if e.IsDir && e.Folded == 0 {
 		dirSuffix = "&sol;"
 	}
-	if len(path) == 0 {
-		path = fmt.Sprintf("%s%s (%s)", t.Value.Name, dirSuffix, sizeCount)
-	} else {
-		path = fmt.Sprintf("%s/%s%s (%s)", path, t.Value.Name, dirSuffix, sizeCount)
+	path := fmt.Sprintf("%s%s (%s)", e.Name, dirSuffix, sizeCount)
+	if len(parent) > 0 {
+		path = parent + "/" + path
 	}
 
 	var v1 float64
 	var v2 float64
+	size := entrySize(e, p.SizeMode)
 	if p.ByCount {
-		v1, v2 = float64(t.Value.Count), float64(t.Value.Size)
+		v1, v2 = float64(entryCount(e, p.CountMode)), float64(size)
 	} else {
-		v1, v2 = float64(t.Value.Size), log(t.Value.Count)
+		v1, v2 = float64(size), log(e.Count)
 	}
 	if p.HeatAge {
-		v2 = p.currTime.Sub(t.Value.Time).Hours() / 24
+		v2 = p.currTime.Sub(entryTime(e, p.AgeStat, p.scanTime)).Hours() / 24
 	}
 
 	fmt.Fprintf(
@@ -77,33 +110,31 @@ func (p TreemapPrinter) print(t *tree.FileTree, sb *strings.Builder, path string
 		v2,
 	)
 
-	if p.ByExtension && t.Value.IsDir {
-		for _, info := range t.Value.Extensions {
+	if (p.ByExtension || p.ByOwner || p.ByCategory) && e.IsDir {
+		for _, info := range groups(e, p.ByOwner, p.ByCategory) {
 			pth := path + "/" + info.Name
+			size := extensionSize(info, p.SizeMode)
 			if p.ByCount {
-				v1, v2 = float64(info.Count), float64(info.Size)
+				v1, v2 = float64(extensionCount(info, p.CountMode)), float64(size)
 			} else {
-				v1, v2 = float64(info.Size), log(info.Count)
+				v1, v2 = float64(size), log(info.Count)
 			}
 			if p.HeatAge {
-				v2 = p.currTime.Sub(info.Time).Hours() / 24
+				v2 = p.currTime.Sub(extensionTime(info, p.AgeStat, p.scanTime)).Hours() / 24
 			}
 			fmt.Fprintf(
 				sb,
-				"%s (%s | %s),%f,%f\n",
+				"%s (%s | %s%s),%f,%f\n",
 				strings.Replace(pth, ",", "-", -1),
-				util.FormatUnitsSimple(info.Size, "B"),
+				formatSizes(info.Size, info.Disk, p.SizeMode, bound),
+				bound,
 				util.FormatUnitsSimple(int64(info.Count), ""),
 				v1,
 				v2,
 			)
 		}
 	}
-	for _, child := range t.Children {
-		if child.Value.IsDir || !(p.ByExtension || p.OnlyDirs) {
-			p.print(child, sb, path)
-		}
-	}
+	return path
 }
 
 func log(n int) float64 {
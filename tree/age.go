@@ -0,0 +1,174 @@
+package tree
+
+import (
+	"time"
+)
+
+const day = 24 * time.Hour
+
+// AgeBins are the upper bounds of the bins of age histograms.
+// The last bin of a histogram is for files older than the last bound.
+var AgeBins = []time.Duration{day, 7 * day, 30 * day, 91 * day, 182 * day, 365 * day, 2 * 365 * day, 5 * 365 * day, 10 * 365 * day}
+
+// Ages is a histogram of file timestamps, by age relative to the time of the analysis.
+// Bin i counts files younger than AgeBins[i], but not younger than AgeBins[i-1].
+type Ages []int
+
+// ageBin returns the histogram bin of timestamp t, relative to reference time ref
+func ageBin(t time.Time, ref time.Time) int {
+	age := ref.Sub(t)
+	for i, b := range AgeBins {
+		if age < b {
+			return i
+		}
+	}
+	return len(AgeBins)
+}
+
+// addAge adds a timestamp t, relative to reference time ref, and returns the histogram.
+// Creates the histogram if it is nil.
+func (a Ages) addAge(t time.Time, ref time.Time) Ages {
+	if a == nil {
+		a = make(Ages, len(AgeBins)+1)
+	}
+	a[ageBin(t, ref)]++
+	return a
+}
+
+// add adds the counts of another histogram, and returns the histogram.
+// Creates the histogram if it is nil.
+func (a Ages) add(other Ages) Ages {
+	if len(other) == 0 {
+		return a
+	}
+	if a == nil {
+		a = make(Ages, len(AgeBins)+1)
+	}
+	for i, c := range other {
+		if i < len(a) {
+			a[i] += c
+		}
+	}
+	return a
+}
+
+// median returns the approximate median timestamp, relative to reference time ref.
+// It is interpolated linearly inside the bin containing the median,
+// and limited to the range between oldest and newest.
+func (a Ages) median(ref time.Time, oldest time.Time, newest time.Time) time.Time {
+	total := 0
+	for _, c := range a {
+		total += c
+	}
+	if total == 0 {
+		return newest
+	}
+	half := float64(total) / 2
+	cum := 0
+	for i, c := range a {
+		if c == 0 || float64(cum+c) < half {
+			cum += c
+			continue
+		}
+		var lo, hi time.Duration
+		if i > 0 {
+			lo = AgeBins[i-1]
+		}
+		if i < len(AgeBins) {
+			hi = AgeBins[i]
+		} else {
+			hi = ref.Sub(oldest)
+		}
+		if youngest := ref.Sub(newest); lo < youngest {
+			lo = youngest
+		}
+		if eldest := ref.Sub(oldest); hi > eldest {
+			hi = eldest
+		}
+		if hi < lo {
+			hi = lo
+		}
+		age := lo + time.Duration(float64(hi-lo)*(half-float64(cum))/float64(c))
+		return ref.Add(-age)
+	}
+	return newest
+}
+
+// AddAge adds timestamp t of a file to the oldest timestamp and the age histogram,
+// relative to ref, the time of the analysis
+func (e *FileEntry) AddAge(t time.Time, ref time.Time) {
+	if t.IsZero() {
+		return
+	}
+	e.Oldest = older(e.Oldest, t)
+	e.Ages = e.Ages.addAge(t, ref)
+}
+
+// AddAges adds the oldest timestamp and the age histogram of another entry
+func (e *FileEntry) AddAges(oldest *time.Time, ages Ages) {
+	if oldest != nil {
+		e.Oldest = older(e.Oldest, *oldest)
+	}
+	e.Ages = e.Ages.add(ages)
+}
+
+// OldestTime returns the oldest timestamp of the files in a directory, or the time of a file.
+// Returns the newest time for entries without an oldest timestamp, like from JSON of previous versions.
+func (e *FileEntry) OldestTime() time.Time {
+	if !e.IsDir || e.Oldest == nil {
+		return e.Time
+	}
+	return *e.Oldest
+}
+
+// MedianTime returns the approximate median timestamp of the files in a directory, or the time of a file.
+// Ref is the time of the analysis, see ScanTime.
+// Returns the newest time for entries without an age histogram, like from JSON of previous versions.
+func (e *FileEntry) MedianTime(ref time.Time) time.Time {
+	if !e.IsDir {
+		return e.Time
+	}
+	return e.Ages.median(ref, e.OldestTime(), e.Time)
+}
+
+// AddAge adds timestamp t of a file to the oldest timestamp and the age histogram,
+// relative to ref, the time of the analysis
+func (e *ExtensionEntry) AddAge(t time.Time, ref time.Time) {
+	if t.IsZero() {
+		return
+	}
+	e.Oldest = older(e.Oldest, t)
+	e.Ages = e.Ages.addAge(t, ref)
+}
+
+// AddAges adds the oldest timestamp and the age histogram of another entry
+func (e *ExtensionEntry) AddAges(oldest *time.Time, ages Ages) {
+	if oldest != nil {
+		e.Oldest = older(e.Oldest, *oldest)
+	}
+	e.Ages = e.Ages.add(ages)
+}
+
+// OldestTime returns the oldest timestamp of the files.
+// Returns the newest time for entries without an oldest timestamp, like from JSON of previous versions.
+func (e *ExtensionEntry) OldestTime() time.Time {
+	if e.Oldest == nil {
+		return e.Time
+	}
+	return *e.Oldest
+}
+
+// MedianTime returns the approximate median timestamp of the files.
+// Ref is the time of the analysis, see ScanTime.
+// Returns the newest time for entries without an age histogram, like from JSON of previous versions.
+func (e *ExtensionEntry) MedianTime(ref time.Time) time.Time {
+	return e.Ages.median(ref, e.OldestTime(), e.Time)
+}
+
+// older returns the older of a timestamp and t, as a new pointer
+func older(oldest *time.Time, t time.Time) *time.Time {
+	if oldest != nil && !t.Before(*oldest) {
+		return oldest
+	}
+	return &t
+}
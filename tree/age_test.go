@@ -0,0 +1,47 @@
+package tree
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntryAges(t *testing.T) {
+	ref := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	year := 365 * 24 * time.Hour
+
+	dir := NewDir("d")
+	assert.True(t, dir.Value.MedianTime(ref).IsZero())
+	assert.True(t, dir.Value.OldestTime().IsZero())
+
+	for _, age := range []time.Duration{time.Hour, 6 * year, 6 * year, 7 * year, 8 * year} {
+		tm := ref.Add(-age)
+		dir.Value.Add(1, 1, 1, tm)
+		dir.Value.AddAge(tm, ref)
+	}
+	assert.Equal(t, ref.Add(-time.Hour), dir.Value.Time)
+	assert.Equal(t, ref.Add(-8*year), dir.Value.OldestTime())
+	assert.Equal(t, Ages{1, 0, 0, 0, 0, 0, 0, 0, 4, 0}, dir.Value.Ages)
+
+	// The median falls into the bin of 5 to 10 years, limited to the oldest file.
+	median := dir.Value.MedianTime(ref)
+	assert.True(t, median.Before(ref.Add(-5*year)))
+	assert.True(t, median.After(ref.Add(-8*year)))
+
+	parent := NewDir("p")
+	parent.Value.AddAges(dir.Value.Oldest, dir.Value.Ages)
+	parent.Value.AddAges(nil, Ages{0, 3})
+	assert.Equal(t, ref.Add(-8*year), parent.Value.OldestTime())
+	assert.Equal(t, Ages{1, 3, 0, 0, 0, 0, 0, 0, 4, 0}, parent.Value.Ages)
+
+	file := NewFile("f", 1, 1, ref)
+	assert.Equal(t, ref, file.Value.OldestTime())
+	assert.Equal(t, ref, file.Value.MedianTime(ref))
+
+	ext := ExtensionEntry{Name: ".txt"}
+	ext.Add(1, 1, 1, ref.Add(-time.Hour))
+	ext.AddAge(ref.Add(-time.Hour), ref)
+	assert.Equal(t, ref.Add(-time.Hour), ext.OldestTime())
+	assert.Equal(t, ref.Add(-time.Hour), ext.MedianTime(ref))
+}
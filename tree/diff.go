@@ -0,0 +1,103 @@
+package tree
+
+// Kinds of changes of diff entries
+const (
+	DiffAdded     string = "added"
+	DiffRemoved   string = "removed"
+	DiffGrown     string = "grown"
+	DiffShrunk    string = "shrunk"
+	DiffUnchanged string = "unchanged"
+)
+
+// DiffTree is a tree with DiffEntry data
+type DiffTree = Tree[*DiffEntry]
+
+// DiffEntry is a diff tree entry, comparing an entry of an old and a new tree
+type DiffEntry struct {
+	Name       string `json:"name"`
+	IsDir      bool   `json:"is_dir"`
+	Change     string `json:"change"`
+	OldSize    int64  `json:"old_size"`
+	NewSize    int64  `json:"new_size"`
+	SizeDelta  int64  `json:"size_delta"`
+	OldDisk    int64  `json:"old_disk"`
+	NewDisk    int64  `json:"new_disk"`
+	DiskDelta  int64  `json:"disk_delta"`
+	OldCount   int    `json:"old_count"`
+	NewCount   int    `json:"new_count"`
+	CountDelta int    `json:"count_delta"`
+}
+
+// NewDiffEntry creates a DiffEntry from an old entry before and a new entry after, where either can be nil.
+//
+// Entries are grown or shrunk by their apparent size, or by their count if the size is unchanged.
+func NewDiffEntry(before, after *FileEntry) DiffEntry {
+	e := DiffEntry{}
+	if before != nil {
+		e.Name, e.IsDir = before.Name, before.IsDir
+		e.OldSize, e.OldDisk, e.OldCount = before.Size, before.Disk, before.Count
+	}
+	if after != nil {
+		e.Name, e.IsDir = after.Name, after.IsDir
+		e.NewSize, e.NewDisk, e.NewCount = after.Size, after.Disk, after.Count
+	}
+	e.SizeDelta = e.NewSize - e.OldSize
+	e.DiskDelta = e.NewDisk - e.OldDisk
+	e.CountDelta = e.NewCount - e.OldCount
+
+	switch {
+	case before == nil:
+		e.Change = DiffAdded
+	case after == nil:
+		e.Change = DiffRemoved
+	case e.SizeDelta > 0 || (e.SizeDelta == 0 && e.CountDelta > 0):
+		e.Change = DiffGrown
+	case e.SizeDelta < 0 || (e.SizeDelta == 0 && e.CountDelta < 0):
+		e.Change = DiffShrunk
+	default:
+		e.Change = DiffUnchanged
+	}
+	return e
+}
+
+// Diff compares an old tree before and a new tree after by path. Either of them can be nil, for an added or a removed tree.
+//
+// The result contains added, removed, grown and shrunk entries.
+// Unchanged entries are only contained if they have changed children. The root is always contained.
+// Entries with the same name, but of different kind (file or directory), are compared as distinct entries.
+func Diff(before, after *FileTree) *DiffTree {
+	var oldValue, newValue *FileEntry
+	var oldChildren, newChildren []*FileTree
+	if before != nil {
+		oldValue, oldChildren = before.Value, before.Children
+	}
+	if after != nil {
+		newValue, newChildren = after.Value, after.Children
+	}
+	e := NewDiffEntry(oldValue, newValue)
+	t := New(&e)
+
+	byKey := make(map[string]*FileTree, len(oldChildren))
+	for _, c := range oldChildren {
+		byKey[childKey(c.Value)] = c
+	}
+	matched := make(map[string]bool, len(newChildren))
+	for _, c := range newChildren {
+		key := childKey(c.Value)
+		matched[key] = true
+		addDiff(t, Diff(byKey[key], c))
+	}
+	for _, c := range oldChildren {
+		if !matched[childKey(c.Value)] {
+			addDiff(t, Diff(c, nil))
+		}
+	}
+	return t
+}
+
+// addDiff adds child to t if it is changed, or has changed children
+func addDiff(t *DiffTree, child *DiffTree) {
+	if child.Value.Change != DiffUnchanged || len(child.Children) > 0 {
+		t.AddTree(child)
+	}
+}
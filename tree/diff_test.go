@@ -0,0 +1,50 @@
+package tree
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	tm := time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)
+	before := createMergeTree(map[string]int64{"a/x.txt": 50, "a/y.go": 20, "b/z.txt": 10, "c/same.txt": 5}, tm)
+	after := createMergeTree(map[string]int64{"a/x.txt": 80, "b/z.txt": 10, "b/w.txt": 0, "c/same.txt": 5, "d/new.txt": 30}, tm)
+
+	diff := Diff(before, after)
+
+	changes := map[string]*DiffEntry{}
+	for p, n := range diff.WithPaths(func(e *DiffEntry) string { return e.Name }) {
+		changes[p] = n.Value
+	}
+	assert.Equal(t, []string{".", "a", "a/x.txt", "a/y.go", "b", "b/w.txt", "d", "d/new.txt"}, sortedKeys(changes))
+
+	assert.Equal(t, DiffGrown, changes["."].Change)
+	assert.Equal(t, int64(40), changes["."].SizeDelta)
+	assert.Equal(t, 1, changes["."].CountDelta)
+
+	assert.Equal(t, DiffGrown, changes["a"].Change)
+	assert.Equal(t, int64(10), changes["a"].SizeDelta)
+	assert.Equal(t, -1, changes["a"].CountDelta)
+	assert.Equal(t, DiffGrown, changes["a/x.txt"].Change)
+	assert.Equal(t, DiffRemoved, changes["a/y.go"].Change)
+	assert.Equal(t, int64(-20), changes["a/y.go"].SizeDelta)
+
+	assert.Equal(t, DiffGrown, changes["b"].Change)
+	assert.Equal(t, int64(0), changes["b"].SizeDelta)
+	assert.Equal(t, DiffAdded, changes["b/w.txt"].Change)
+
+	assert.Equal(t, DiffAdded, changes["d"].Change)
+	assert.Equal(t, int64(30), changes["d/new.txt"].NewSize)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
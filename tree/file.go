@@ -13,42 +13,188 @@ type FileTree = Tree[*FileEntry]
 
 // NewDir creates a new FileTree with a directory entry
 func NewDir(name string) *FileTree {
-	e := NewFileEntry(name, 0, tm.Time{}, true)
+	e := NewFileEntry(name, 0, 0, tm.Time{}, true)
 	t := New(&e)
 	return t
 }
 
+// FilePath returns the slash-separated path of an entry relative to the root of its tree, or "." for the root
+func FilePath(t *FileTree) string {
+	return t.Path(entryName)
+}
+
+// FindFiles returns all entries at a path relative to t, see Tree.Find
+func FindFiles(t *FileTree, path string, caseSensitive bool) ([]*FileTree, error) {
+	return t.Find(path, entryName, caseSensitive)
+}
+
+func entryName(e *FileEntry) string {
+	return e.Name
+}
+
+// OtherName is the name of the entries for content folded by PruneFiles
+const OtherName = "<other>"
+
+// PruneFiles removes all entries for which drop returns true, except for the root.
+// Drop is called with ref, the time of the analysis from the root's ScanTime or the current time, for calculating ages.
+//
+// The entries dropped from a directory are folded into a new directory entry named OtherName,
+// so that the children of each directory still sum up to its totals. Its Folded is the number of dropped entries.
+// It contains the extensions, owners and categories of dropped directories.
+// Those of dropped files are already contained in their parent directory.
+func PruneFiles(t *FileTree, drop func(e *FileEntry, ref tm.Time) bool) {
+	ref := tm.Now()
+	if t.Value.ScanTime != nil {
+		ref = *t.Value.ScanTime
+	}
+	t.Prune(func(e *FileEntry) bool { return drop(e, ref) },
+		func(parent *FileEntry) *FileEntry {
+			e := NewFileEntry(OtherName, 0, 0, tm.Time{}, true)
+			return &e
+		},
+		func(other *FileEntry, dropped *FileTree) {
+			v := dropped.Value
+			other.Folded++
+			other.Add(v.Size, v.Disk, v.Count, v.Time)
+			if v.IsDir {
+				other.AddAges(v.Oldest, v.Ages)
+			} else {
+				other.AddAge(v.Time, ref)
+			}
+			other.AddCounts(v)
+			other.Linked += v.Linked
+			other.Incomplete = other.Incomplete || v.Incomplete
+			for c := range dropped.All() {
+				if c.Value.IsDir {
+					other.AddExtensions(c.Value.Extensions)
+					other.AddOwners(c.Value.Owners)
+					other.AddCategories(c.Value.Categories)
+				}
+				other.Errors = append(other.Errors, c.Value.Errors...)
+			}
+		})
+}
+
 // NewFile creates a new FileTree with a file entry
-func NewFile(name string, size int64, time tm.Time) *FileTree {
-	e := NewFileEntry(name, size, time, false)
+func NewFile(name string, size int64, disk int64, time tm.Time) *FileTree {
+	e := NewFileEntry(name, size, disk, time, false)
 	t := New(&e)
 	return t
 }
 
-// FileEntry is a file tree entry
+// FileEntry is a file tree entry.
+//
+// Count is the number of all entries except directories, i.e. of files, links and special files.
+// Dirs and Empty are the numbers of directories and empty directories, including the directory itself.
+// Folded is the number of entries combined into a pseudo-directory, like by PruneFiles. It is zero for real directories.
 type FileEntry struct {
 	Name       string                     `json:"name"`
 	IsDir      bool                       `json:"is_dir"`
 	Size       int64                      `json:"size"`
+	Disk       int64                      `json:"disk"`
+	Linked     int64                      `json:"linked,omitempty"`
+	HardLinked bool                       `json:"hard_linked,omitempty"`
 	Count      int                        `json:"count"`
+	Dirs       int                        `json:"dirs,omitempty"`
+	Empty      int                        `json:"empty,omitempty"`
+	Links      int                        `json:"links,omitempty"`
+	Special    int                        `json:"special,omitempty"`
 	Time       time.Time                  `json:"time"`
+	TimeKind   string                     `json:"time_kind,omitempty"`
+	ScanTime   *time.Time                 `json:"scan_time,omitempty"`
+	Oldest     *time.Time                 `json:"oldest,omitempty"`
+	Ages       Ages                       `json:"ages,omitempty"`
+	DirTime    *time.Time                 `json:"dir_time,omitempty"`
+	Link       string                     `json:"link,omitempty"`
+	Owner      string                     `json:"owner,omitempty"`
+	Category   string                     `json:"category,omitempty"`
+	Incomplete bool                       `json:"incomplete,omitempty"`
+	Unscanned  string                     `json:"unscanned,omitempty"`
+	Archive    string                     `json:"archive,omitempty"`
+	Folded     int                        `json:"folded,omitempty"`
+	Errors     []PathError                `json:"errors,omitempty"`
 	Extensions map[string]*ExtensionEntry `json:"extensions"`
+	Owners     map[string]*OwnerEntry     `json:"owners"`
+	Categories map[string]*CategoryEntry  `json:"categories"`
+	Largest    []LargeFile                `json:"largest,omitempty"`
+}
+
+// Reasons for directories that were not scanned, and are only placeholders
+const (
+	UnscannedFileSystem string = "other file system"
+	UnscannedDepth      string = "scan depth"
+)
+
+// Formats of archives, which are expanded to virtual directories.
+// Inside archives, the size of entries is the uncompressed size,
+// and the disk size is the compressed size.
+const (
+	ArchiveZip   string = "zip"
+	ArchiveTar   string = "tar"
+	ArchiveTarGz string = "tar.gz"
+)
+
+// Kinds of file timestamps used for Time. Recorded in TimeKind of the root.
+// An empty TimeKind is the modification time.
+const (
+	TimeModified string = "mtime"
+	TimeAccessed string = "atime"
+	TimeChanged  string = "ctime"
+	TimeBirth    string = "btime"
+)
+
+// Kinds of errors for paths that could not be read
+const (
+	ErrorPermission string = "permission"
+	ErrorNotExist   string = "not-exist"
+	ErrorOther      string = "other"
+)
+
+// PathError is a path that could not be read during the analysis
+type PathError struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+}
+
+// LargeFile is one of the largest files of an analysis, recorded in the root entry.
+// Path is slash-separated and relative to the analyzed directory.
+type LargeFile struct {
+	Path string    `json:"path"`
+	Size int64     `json:"size"`
+	Disk int64     `json:"disk"`
+	Time time.Time `json:"time"`
 }
 
 // ExtensionEntry is a file tree entry for extensions
 type ExtensionEntry struct {
-	Name  string    `json:"name"`
-	Size  int64     `json:"size"`
-	Count int       `json:"count"`
-	Time  time.Time `json:"time"`
+	Name   string     `json:"name"`
+	Size   int64      `json:"size"`
+	Disk   int64      `json:"disk"`
+	Count  int        `json:"count"`
+	Time   time.Time  `json:"time"`
+	Oldest *time.Time `json:"oldest,omitempty"`
+	Ages   Ages       `json:"ages,omitempty"`
 }
 
-// NewFileEntry creates a new FileEntry
-func NewFileEntry(name string, size int64, time tm.Time, isDir bool) FileEntry {
+// OwnerEntry is a file tree entry for owners, as "user:group".
+// It has the same fields as ExtensionEntry.
+type OwnerEntry = ExtensionEntry
+
+// CategoryEntry is a file tree entry for categories of files, like images or source code.
+// It has the same fields as ExtensionEntry.
+type CategoryEntry = ExtensionEntry
+
+// NewFileEntry creates a new FileEntry.
+// Size is the apparent size, disk the allocated size on disk
+func NewFileEntry(name string, size int64, disk int64, time tm.Time, isDir bool) FileEntry {
 	count := 0
 	var ext map[string]*ExtensionEntry = nil
+	var owners map[string]*OwnerEntry = nil
+	var categories map[string]*CategoryEntry = nil
 	if isDir {
 		ext = map[string]*ExtensionEntry{}
+		owners = map[string]*OwnerEntry{}
+		categories = map[string]*CategoryEntry{}
 		time = tm.Time{}
 	} else {
 		count = 1
@@ -57,25 +203,43 @@ func NewFileEntry(name string, size int64, time tm.Time, isDir bool) FileEntry {
 		Name:       name,
 		IsDir:      isDir,
 		Size:       size,
+		Disk:       disk,
 		Count:      count,
 		Time:       time,
 		Extensions: ext,
+		Owners:     owners,
+		Categories: categories,
 	}
 }
 
-// Add adds size and a count
-func (e *FileEntry) Add(size int64, count int, time tm.Time) {
+// AddCounts adds the numbers of directories, empty directories, links and special files of another entry
+func (e *FileEntry) AddCounts(other *FileEntry) {
+	e.Dirs += other.Dirs
+	e.Empty += other.Empty
+	e.Links += other.Links
+	e.Special += other.Special
+}
+
+// Files returns the number of regular files, i.e. the count without links and special files
+func (e *FileEntry) Files() int {
+	return e.Count - e.Links - e.Special
+}
+
+// Add adds size, disk size and a count
+func (e *FileEntry) Add(size int64, disk int64, count int, time tm.Time) {
 	e.Count += count
 	e.Size += size
+	e.Disk += disk
 	if !time.IsZero() && (e.Time.IsZero() || time.After(e.Time)) {
 		e.Time = time
 	}
 }
 
-// Add adds size and a count
-func (e *ExtensionEntry) Add(size int64, count int, time tm.Time) {
+// Add adds size, disk size and a count
+func (e *ExtensionEntry) Add(size int64, disk int64, count int, time tm.Time) {
 	e.Count += count
 	e.Size += size
+	e.Disk += disk
 	if !time.IsZero() && (e.Time.IsZero() || time.After(e.Time)) {
 		e.Time = time
 	}
@@ -94,12 +258,34 @@ func (e FileEntry) String() string {
 
 // AddExtensions adds extensions
 func (e *FileEntry) AddExtensions(ext map[string]*ExtensionEntry) {
-	for k, v := range ext {
-		if inf, ok := e.Extensions[k]; ok {
-			inf.Add(v.Size, v.Count, v.Time)
+	e.Extensions = addEntries(e.Extensions, ext)
+}
+
+// AddOwners adds owners
+func (e *FileEntry) AddOwners(owners map[string]*OwnerEntry) {
+	e.Owners = addEntries(e.Owners, owners)
+}
+
+// AddCategories adds categories
+func (e *FileEntry) AddCategories(categories map[string]*CategoryEntry) {
+	e.Categories = addEntries(e.Categories, categories)
+}
+
+// addEntries adds the entries of src to dst, and returns dst.
+// Creates dst if it is nil, as for JSON from previous versions.
+func addEntries(dst map[string]*ExtensionEntry, src map[string]*ExtensionEntry) map[string]*ExtensionEntry {
+	if dst == nil && len(src) > 0 {
+		dst = map[string]*ExtensionEntry{}
+	}
+	for k, v := range src {
+		if inf, ok := dst[k]; ok {
+			inf.Add(v.Size, v.Disk, v.Count, v.Time)
+			inf.AddAges(v.Oldest, v.Ages)
 		} else {
-			fe := ExtensionEntry{k, v.Size, v.Count, v.Time}
-			e.Extensions[k] = &fe
+			fe := ExtensionEntry{Name: k, Size: v.Size, Disk: v.Disk, Count: v.Count, Time: v.Time}
+			fe.AddAges(v.Oldest, v.Ages)
+			dst[k] = &fe
 		}
 	}
+	return dst
 }
@@ -8,11 +8,12 @@ import (
 )
 
 func TestEntryCreate(t *testing.T) {
-	file := NewFile("f", 1, time.Time{})
+	file := NewFile("f", 1, 4096, time.Time{})
 	dir := NewDir("d")
 
 	assert.Equal(t, "f", file.Value.Name)
 	assert.Equal(t, int64(1), file.Value.Size)
+	assert.Equal(t, int64(4096), file.Value.Disk)
 	assert.Equal(t, 1, file.Value.Count)
 
 	assert.Equal(t, "d", dir.Value.Name)
@@ -26,8 +27,9 @@ func TestEntryAdd(t *testing.T) {
 	dir := NewDir("d")
 	assert.Equal(t, true, dir.Value.Time.IsZero())
 
-	dir.Value.Add(100, 1, tm)
+	dir.Value.Add(100, 4096, 1, tm)
 	assert.Equal(t, int64(100), dir.Value.Size)
+	assert.Equal(t, int64(4096), dir.Value.Disk)
 	assert.Equal(t, 1, dir.Value.Count)
 	assert.Equal(t, tm, dir.Value.Time)
 }
@@ -37,6 +39,52 @@ func TestEntryAddExtensions(t *testing.T) {
 
 	dir := NewDir("d")
 
-	dir.Value.AddExtensions(map[string]*ExtensionEntry{".exe": {Name: ".exe", Size: 100, Count: 10, Time: tm}})
-	assert.Equal(t, map[string]*ExtensionEntry{".exe": {Name: ".exe", Size: 100, Count: 10, Time: tm}}, dir.Value.Extensions)
+	dir.Value.AddExtensions(map[string]*ExtensionEntry{".exe": {Name: ".exe", Size: 100, Disk: 4096, Count: 10, Time: tm}})
+	assert.Equal(t, map[string]*ExtensionEntry{".exe": {Name: ".exe", Size: 100, Disk: 4096, Count: 10, Time: tm}}, dir.Value.Extensions)
+}
+
+func TestEntryAddOwners(t *testing.T) {
+	tm := time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	dir := NewDir("d")
+	dir.Value.Owners = nil
+
+	dir.Value.AddOwners(map[string]*OwnerEntry{"alice:staff": {Name: "alice:staff", Size: 100, Disk: 4096, Count: 10, Time: tm}})
+	dir.Value.AddOwners(map[string]*OwnerEntry{"alice:staff": {Name: "alice:staff", Size: 50, Disk: 4096, Count: 5, Time: tm}})
+	assert.Equal(t, map[string]*OwnerEntry{"alice:staff": {Name: "alice:staff", Size: 150, Disk: 8192, Count: 15, Time: tm}}, dir.Value.Owners)
+}
+
+func TestPruneFiles(t *testing.T) {
+	tm := time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	root := NewDir("root")
+	sub := NewDir("sub")
+	sub.Value.AddExtensions(map[string]*ExtensionEntry{".txt": {Name: ".txt", Size: 10, Disk: 10, Count: 1, Time: tm}})
+	sub.Value.Add(10, 10, 1, tm)
+	sub.Value.Dirs = 1
+	sub.AddTree(NewFile("small.txt", 10, 10, tm))
+	root.AddTree(sub)
+	root.AddTree(NewFile("large.bin", 1000, 1000, tm))
+	root.AddTree(NewFile("tiny.bin", 1, 1, tm))
+	root.Aggregate(func(parent, child *FileEntry) {
+		if child.IsDir {
+			parent.Add(child.Size, child.Disk, child.Count, child.Time)
+			parent.AddCounts(child)
+		}
+	})
+
+	PruneFiles(root, func(e *FileEntry, ref time.Time) bool { return e.Size < 100 })
+
+	assert.Equal(t, 2, len(root.Children))
+	assert.Equal(t, "large.bin", root.Children[0].Value.Name)
+
+	other := root.Children[1].Value
+	assert.Equal(t, OtherName, other.Name)
+	assert.True(t, other.IsDir)
+	assert.Equal(t, 2, other.Folded)
+	assert.Equal(t, int64(11), other.Size)
+	assert.Equal(t, 2, other.Count)
+	assert.Equal(t, 1, other.Dirs)
+	assert.Equal(t, tm, other.OldestTime())
+	assert.Equal(t, 1, other.Extensions[".txt"].Count)
 }
@@ -0,0 +1,238 @@
+package tree
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Policies for files contained in several trees, see Merge
+const (
+	MergeSum     string = "sum"     // Sum up sizes and counts, as for distinct files
+	MergeFirst   string = "first"   // Keep the file from the first tree
+	MergeLast    string = "last"    // Keep the file from the last tree
+	MergeLargest string = "largest" // Keep the file with the largest apparent size
+	MergeNewest  string = "newest"  // Keep the file with the newest timestamp
+)
+
+// MergePolicies are all policies for files contained in several trees
+var MergePolicies = []string{MergeSum, MergeFirst, MergeLast, MergeLargest, MergeNewest}
+
+// Merge unions trees by path. The first tree is modified and returned,
+// and the other trees must not be used afterwards, as sub-trees are moved.
+//
+// Sizes, counts, extensions, owners and categories of directories contained in several trees are summed up.
+// Files contained in several trees are resolved using policy, one of the Merge... constants.
+// Entries with the same name, but of different kind (file or directory), are kept separately.
+// Children of the result are sorted in the order of a walk, see SortChildren.
+//
+// Age histograms are relative to the time of each analysis, and can only be merged approximately.
+// When discarding files, timestamps and ages of their directories are not corrected.
+func Merge(policy string, trees ...*FileTree) (*FileTree, error) {
+	if len(trees) == 0 {
+		return nil, fmt.Errorf("nothing to merge")
+	}
+	if !isMergePolicy(policy) {
+		return nil, fmt.Errorf("unknown merge policy '%s'. Must be one of [%s]", policy, strings.Join(MergePolicies, ", "))
+	}
+	dst := trees[0]
+	m := merger{policy: policy, kept: map[string]*FileEntry{}}
+	numLargest := len(dst.Value.Largest)
+	for _, src := range trees[1:] {
+		if kind(dst.Value) != kind(src.Value) {
+			return nil, fmt.Errorf("can't merge trees with timestamps %s and %s", kind(dst.Value), kind(src.Value))
+		}
+		if src.Value.ScanTime != nil && (dst.Value.ScanTime == nil || src.Value.ScanTime.After(*dst.Value.ScanTime)) {
+			dst.Value.ScanTime = src.Value.ScanTime
+		}
+		if len(src.Value.Largest) > numLargest {
+			numLargest = len(src.Value.Largest)
+		}
+		dst.Value.Largest = append(dst.Value.Largest, src.Value.Largest...)
+		m.mergeDir(dst, src, "")
+	}
+	SortChildren(dst)
+	dst.Value.Largest = m.largest(dst.Value.Largest, numLargest)
+	return dst, nil
+}
+
+// merger merges trees, see Merge
+type merger struct {
+	policy string
+	kept   map[string]*FileEntry // Files kept from conflicts, by path, for filtering the largest files
+}
+
+// mergeDir merges directory src into dst. Path is the slash-separated path of dst, empty for the root
+func (m *merger) mergeDir(dst, src *FileTree, path string) {
+	d, s := dst.Value, src.Value
+	dirs, empty := 0, 0
+	if d.Dirs > 0 && s.Dirs > 0 {
+		// The directory itself is counted in both trees.
+		dirs = 1
+		if isEmptyDir(d) || isEmptyDir(s) {
+			empty = 1
+		}
+	}
+
+	d.Add(s.Size, s.Disk, s.Count, s.Time)
+	d.AddAges(s.Oldest, s.Ages)
+	d.AddCounts(s)
+	d.Linked += s.Linked
+	d.AddExtensions(s.Extensions)
+	d.AddOwners(s.Owners)
+	d.AddCategories(s.Categories)
+	d.Errors = append(d.Errors, s.Errors...)
+	d.Folded += s.Folded
+	d.Incomplete = d.Incomplete || s.Incomplete
+	if (len(d.Unscanned) == 0) != (len(s.Unscanned) == 0) {
+		// Only scanned in one of the trees.
+		d.Unscanned = ""
+		d.Incomplete = true
+	}
+	if s.DirTime != nil && (d.DirTime == nil || s.DirTime.After(*d.DirTime)) {
+		d.DirTime = s.DirTime
+	}
+	for p := dst; p != nil; p = p.Parent() {
+		p.Value.Dirs -= dirs
+		p.Value.Empty -= empty
+	}
+
+	children := map[string]*FileTree{}
+	for _, c := range dst.Children {
+		children[childKey(c.Value)] = c
+	}
+	for _, sc := range src.Children {
+		dc, ok := children[childKey(sc.Value)]
+		if !ok {
+			dst.AddTree(sc)
+			continue
+		}
+		childPath := sc.Value.Name
+		if len(path) > 0 {
+			childPath = path + "/" + childPath
+		}
+		if sc.Value.IsDir {
+			m.mergeDir(dc, sc, childPath)
+		} else {
+			m.mergeFile(dc, sc, childPath)
+		}
+	}
+}
+
+// mergeFile merges file src into file dst, according to the policy
+func (m *merger) mergeFile(dst, src *FileTree, path string) {
+	d, s := dst.Value, src.Value
+	if m.policy == MergeSum {
+		d.Add(s.Size, s.Disk, s.Count, s.Time)
+		d.AddCounts(s)
+		d.Linked += s.Linked
+		return
+	}
+	keepSrc := false
+	switch m.policy {
+	case MergeLast:
+		keepSrc = true
+	case MergeLargest:
+		keepSrc = s.Size > d.Size
+	case MergeNewest:
+		keepSrc = s.Time.After(d.Time)
+	}
+	if keepSrc {
+		removeFile(dst.Parent(), d)
+		dst.Value = s
+	} else {
+		removeFile(dst.Parent(), s)
+	}
+	m.kept[path] = dst.Value
+}
+
+// largest returns the largest files, without those discarded in conflicts, limited to n entries
+func (m *merger) largest(files []LargeFile, n int) []LargeFile {
+	result := []LargeFile{}
+	seen := map[string]bool{}
+	for _, f := range files {
+		if e, ok := m.kept[f.Path]; ok {
+			if seen[f.Path] || e.Size != f.Size || !e.Time.Equal(f.Time) {
+				continue
+			}
+			seen[f.Path] = true
+		}
+		result = append(result, f)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Size != result[j].Size {
+			return result[i].Size > result[j].Size
+		}
+		return result[i].Path < result[j].Path
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// removeFile removes the sizes and counts of file f from parent and its ancestors,
+// and from the extensions, owners and categories of parent
+func removeFile(parent *FileTree, f *FileEntry) {
+	for p := parent; p != nil; p = p.Parent() {
+		v := p.Value
+		v.Size -= f.Size
+		v.Disk -= f.Disk
+		v.Count -= f.Count
+		v.Links -= f.Links
+		v.Special -= f.Special
+		v.Linked -= f.Linked
+	}
+	v := parent.Value
+	removeEntry(v.Extensions, filepath.Ext(f.Name), f)
+	removeEntry(v.Owners, f.Owner, f)
+	removeEntry(v.Categories, f.Category, f)
+}
+
+// removeEntry removes the sizes and count of file f from the entry with the given name
+func removeEntry(entries map[string]*ExtensionEntry, name string, f *FileEntry) {
+	e, ok := entries[name]
+	if !ok {
+		return
+	}
+	e.Size -= f.Size
+	e.Disk -= f.Disk
+	e.Count -= f.Count
+	if e.Count <= 0 {
+		delete(entries, name)
+	}
+}
+
+// isEmptyDir returns whether e is a directory without any entries
+func isEmptyDir(e *FileEntry) bool {
+	return e.Dirs == 1 && e.Empty == 1 && e.Count == 0
+}
+
+// childKey returns a key of an entry that identifies it among its siblings
+func childKey(e *FileEntry) string {
+	if e.IsDir {
+		return e.Name + "/"
+	}
+	return e.Name
+}
+
+// kind returns the kind of timestamps of a root entry
+func kind(e *FileEntry) string {
+	if len(e.TimeKind) == 0 {
+		return TimeModified
+	}
+	return e.TimeKind
+}
+
+func isMergePolicy(policy string) bool {
+	for _, p := range MergePolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
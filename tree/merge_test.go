@@ -0,0 +1,113 @@
+package tree
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createMergeTree creates a tree of files with the given sizes, by slash-separated path,
+// with directories and totals like from a walk. Paths ending with a slash are empty directories.
+func createMergeTree(files map[string]int64, tm time.Time) *FileTree {
+	root := NewDir("root")
+	root.Value.Dirs = 1
+	for path, size := range files {
+		elems := strings.Split(path, "/")
+		dir := root
+		for _, name := range elems[:len(elems)-1] {
+			found, _ := dir.Find(name, entryName, true)
+			if len(found) == 0 {
+				sub := NewDir(name)
+				sub.Value.Dirs = 1
+				dir.AddTree(sub)
+				found = append(found, sub)
+			}
+			dir = found[0]
+		}
+		name := elems[len(elems)-1]
+		if len(name) == 0 {
+			dir.Value.Empty = 1
+			continue
+		}
+		file := NewFile(name, size, size, tm)
+		dir.AddTree(file)
+		dir.Value.Add(size, size, 1, tm)
+		dir.Value.AddExtensions(map[string]*ExtensionEntry{filepath.Ext(name): {Size: size, Disk: size, Count: 1, Time: tm}})
+	}
+	root.Aggregate(func(parent, child *FileEntry) {
+		if child.IsDir {
+			parent.Add(child.Size, child.Disk, child.Count, child.Time)
+			parent.AddCounts(child)
+		}
+	})
+	return root
+}
+
+func TestMerge(t *testing.T) {
+	tm := time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	for _, test := range []struct {
+		policy  string
+		size    int64
+		count   int
+		txt     int64
+		largest int64
+	}{
+		{MergeSum, 90, 4, 60, 50},
+		{MergeFirst, 80, 3, 50, 50},
+		{MergeLast, 40, 3, 10, 10},
+		{MergeLargest, 80, 3, 50, 50},
+		{MergeNewest, 80, 3, 50, 50},
+	} {
+		a := createMergeTree(map[string]int64{"a/x.txt": 50, "a/y.go": 20, "e/": 0}, tm)
+		b := createMergeTree(map[string]int64{"a/x.txt": 10, "b/z.txt": 10, "e/": 0}, tm)
+		a.Value.Largest = []LargeFile{{Path: "a/x.txt", Size: 50, Time: tm}}
+		b.Value.Largest = []LargeFile{{Path: "a/x.txt", Size: 10, Time: tm}}
+
+		merged, err := Merge(test.policy, a, b)
+		assert.Nil(t, err)
+
+		assert.Equal(t, test.size, merged.Value.Size, test.policy)
+		assert.Equal(t, test.count, merged.Value.Count, test.policy)
+		assert.Equal(t, 4, merged.Value.Dirs, test.policy)
+		assert.Equal(t, 1, merged.Value.Empty, test.policy)
+		assert.Equal(t, 3, len(merged.Children), test.policy)
+
+		found, err := FindFiles(merged, "a", true)
+		assert.Nil(t, err)
+		dir := found[0]
+		assert.Equal(t, test.size-10, dir.Value.Size, test.policy)
+		assert.Equal(t, test.txt, dir.Value.Extensions[".txt"].Size, test.policy)
+		assert.Equal(t, 1, dir.Value.Dirs, test.policy)
+		assert.Equal(t, 2, len(dir.Children), test.policy)
+
+		assert.Equal(t, "root", merged.Value.Name)
+		found, err = FindFiles(merged, "b/z.txt", true)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(found), test.policy)
+		assert.Equal(t, 1, len(merged.Value.Largest), test.policy)
+		assert.Equal(t, test.largest, merged.Value.Largest[0].Size, test.policy)
+	}
+
+	// Children only contained in later trees are sorted in, with directories first.
+	a := createMergeTree(map[string]int64{"b/x.txt": 10, "d.txt": 10}, tm)
+	b := createMergeTree(map[string]int64{"A/x.txt": 10, "c/": 0, "B.txt": 10, "e.txt": 10}, tm)
+	merged, err := Merge(MergeSum, a, b)
+	assert.Nil(t, err)
+	names := []string{}
+	for _, c := range merged.Children {
+		names = append(names, c.Value.Name)
+	}
+	assert.Equal(t, []string{"A", "b", "c", "B.txt", "d.txt", "e.txt"}, names)
+
+	_, err = Merge("unknown", NewDir("a"), NewDir("b"))
+	assert.NotNil(t, err)
+
+	a, b = NewDir("a"), NewDir("b")
+	b.Value.TimeKind = TimeAccessed
+	_, err = Merge(MergeSum, a, b)
+	assert.NotNil(t, err)
+}
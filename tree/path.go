@@ -0,0 +1,100 @@
+package tree
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Parent returns the parent of the tree, or nil for a root
+func (t *Tree[T]) Parent() *Tree[T] {
+	return t.parent
+}
+
+// LinkParents links all sub-trees to their parents.
+// Required after modifying Children directly, and after unmarshalling.
+func (t *Tree[T]) LinkParents() {
+	for _, child := range t.Children {
+		child.parent = t
+		child.LinkParents()
+	}
+}
+
+// Detach removes the tree from the children of its parent, and makes it a root
+func (t *Tree[T]) Detach() {
+	if t.parent == nil {
+		return
+	}
+	p := t.parent
+	for i, child := range p.Children {
+		if child == t {
+			p.Children = append(p.Children[:i], p.Children[i+1:]...)
+			break
+		}
+	}
+	t.parent = nil
+}
+
+// Path returns the slash-separated path of the tree relative to its root,
+// made of the names of the nodes, from function name. The path of a root is ".".
+func (t *Tree[T]) Path(name func(T) string) string {
+	if t.parent == nil {
+		return "."
+	}
+	elems := []string{}
+	for n := t; n.parent != nil; n = n.parent {
+		elems = append(elems, name(n.Value))
+	}
+	for i, j := 0, len(elems)-1; i < j; i, j = i+1, j-1 {
+		elems[i], elems[j] = elems[j], elems[i]
+	}
+	return strings.Join(elems, "/")
+}
+
+// Find returns all sub-trees at a path relative to the tree, in tree order.
+// Names of the nodes are from function name. Returns no error if nothing is found.
+//
+// The path can be slash-separated, or use the separator of the OS.
+// Elements can be glob patterns, as supported by path.Match, like "src/*/testdata".
+func (t *Tree[T]) Find(p string, name func(T) string, caseSensitive bool) ([]*Tree[T], error) {
+	elems := SplitPath(p)
+	for i, e := range elems {
+		if _, err := path.Match(e, ""); err != nil {
+			return nil, fmt.Errorf("invalid path pattern '%s'", e)
+		}
+		if !caseSensitive {
+			elems[i] = strings.ToLower(e)
+		}
+	}
+
+	matches := []*Tree[T]{t}
+	for _, e := range elems {
+		var next []*Tree[T]
+		for _, m := range matches {
+			for _, child := range m.Children {
+				n := name(child.Value)
+				if !caseSensitive {
+					n = strings.ToLower(n)
+				}
+				if ok, _ := path.Match(e, n); ok {
+					next = append(next, child)
+				}
+			}
+		}
+		matches = next
+	}
+	return matches, nil
+}
+
+// SplitPath splits a slash-separated path, or a path with the separator of the OS, into its elements.
+// Empty elements and "." are skipped.
+func SplitPath(p string) []string {
+	elems := []string{}
+	for _, e := range strings.Split(filepath.ToSlash(p), "/") {
+		if len(e) > 0 && e != "." {
+			elems = append(elems, e)
+		}
+	}
+	return elems
+}
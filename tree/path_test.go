@@ -0,0 +1,80 @@
+package tree
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func identity(v string) string {
+	return v
+}
+
+func TestTreeParent(t *testing.T) {
+	tr := createWalkTree()
+	b := tr.Children[0]
+	d := b.Children[0]
+
+	assert.Nil(t, tr.Parent())
+	assert.Equal(t, tr, b.Parent())
+	assert.Equal(t, b, d.Parent())
+
+	assert.Equal(t, ".", tr.Path(identity))
+	assert.Equal(t, "b", b.Path(identity))
+	assert.Equal(t, "b/d", d.Path(identity))
+
+	b.Detach()
+	assert.Nil(t, b.Parent())
+	assert.Equal(t, 1, len(tr.Children))
+	assert.Equal(t, "d", d.Path(identity))
+
+	b.Children = append(b.Children, New("f"))
+	b.LinkParents()
+	assert.Equal(t, "f", b.Children[2].Path(identity))
+}
+
+func TestTreeFind(t *testing.T) {
+	tr := New("root")
+	src := New("src")
+	for _, name := range []string{"a", "B"} {
+		pkg := New(name)
+		pkg.Add("testdata")
+		pkg.Add("main.go")
+		src.AddTree(pkg)
+	}
+	tr.AddTree(src)
+
+	paths := func(trees []*Tree[string]) []string {
+		result := []string{}
+		for _, t := range trees {
+			result = append(result, t.Path(identity))
+		}
+		return result
+	}
+
+	found, err := tr.Find("src/*/testdata", identity, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"src/a/testdata", "src/B/testdata"}, paths(found))
+
+	found, err = tr.Find(filepath.Join(".", "src", "b"), identity, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"src/B"}, paths(found))
+
+	found, err = tr.Find("src/b", identity, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(found))
+
+	found, err = tr.Find("/", identity, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Tree[string]{tr}, found)
+
+	_, err = tr.Find("src/[", identity, true)
+	assert.NotNil(t, err)
+}
+
+func TestSplitPath(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", "c"}, SplitPath("./a//b/c/"))
+	assert.Equal(t, []string{"a", "b"}, SplitPath(filepath.Join("a", "b")))
+	assert.Equal(t, []string{}, SplitPath("."))
+}
@@ -9,5 +9,6 @@ func Deserialize(bytes []byte) (*FileTree, error) {
 	if err != nil {
 		return nil, err
 	}
+	t.LinkParents()
 	return t, nil
 }
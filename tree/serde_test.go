@@ -11,7 +11,7 @@ import (
 func TestDeserialize(t *testing.T) {
 	tr := NewDir("root")
 	tr.AddTree(NewDir("b"))
-	tr.AddTree(NewFile("c", 100, time.Time{}))
+	tr.AddTree(NewFile("c", 100, 4096, time.Time{}))
 	tr.Children[0].AddTree(NewDir("d"))
 
 	b, err := json.MarshalIndent(tr, "", "    ")
@@ -21,4 +21,5 @@ func TestDeserialize(t *testing.T) {
 	assert.Equal(t, nil, err)
 
 	assert.Equal(t, tr, tr2)
+	assert.Equal(t, "b/d", FilePath(tr2.Children[0].Children[0]))
 }
@@ -0,0 +1,53 @@
+package tree
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SortChildren sorts the children of t and of all its sub-trees in the order of a walk, see LessFile
+func SortChildren(t *FileTree) {
+	for n := range t.All() {
+		sort.SliceStable(n.Children, func(i, j int) bool {
+			a, b := n.Children[i].Value, n.Children[j].Value
+			return LessFile(a.Name, a.IsDir, b.Name, b.IsDir)
+		})
+	}
+}
+
+// LessFile reports whether an entry named a sorts before an entry named b in a walk.
+// Directories come first, and names are compared case-insensitive.
+func LessFile(a string, aIsDir bool, b string, bIsDir bool) bool {
+	if aIsDir != bIsDir {
+		return aIsDir
+	}
+	return lessCaseInsensitive(a, b)
+}
+
+// lessCaseInsensitive compares s, t without allocating
+func lessCaseInsensitive(s, t string) bool {
+	for {
+		if len(t) == 0 {
+			return false
+		}
+		if len(s) == 0 {
+			return true
+		}
+		c, sizec := utf8.DecodeRuneInString(s)
+		d, sized := utf8.DecodeRuneInString(t)
+
+		lowerc := unicode.ToLower(c)
+		lowerd := unicode.ToLower(d)
+
+		if lowerc < lowerd {
+			return true
+		}
+		if lowerc > lowerd {
+			return false
+		}
+
+		s = s[sizec:]
+		t = t[sized:]
+	}
+}
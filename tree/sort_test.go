@@ -0,0 +1,34 @@
+package tree
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortChildren(t *testing.T) {
+	root := NewDir("root")
+	sub := NewDir("b")
+	sub.AddTree(NewFile("Z.txt", 1, 1, time.Time{}))
+	sub.AddTree(NewFile("a.txt", 1, 1, time.Time{}))
+	root.AddTree(NewFile("c.txt", 1, 1, time.Time{}))
+	root.AddTree(sub)
+	root.AddTree(NewDir("A"))
+
+	SortChildren(root)
+
+	names := func(t *FileTree) []string {
+		result := []string{}
+		for _, c := range t.Children {
+			result = append(result, c.Value.Name)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"A", "b", "c.txt"}, names(root))
+	assert.Equal(t, []string{"a.txt", "Z.txt"}, names(sub))
+
+	assert.True(t, LessFile("b", true, "a", false))
+	assert.True(t, LessFile("a", false, "B", false))
+	assert.False(t, LessFile("B", false, "b", false))
+}
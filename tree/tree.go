@@ -1,13 +1,11 @@
 package tree
 
-import (
-	"fmt"
-)
-
-// Tree is a tree data structure
+// Tree is a tree data structure.
+// Children added with Add and AddTree are linked to their parent, see Parent.
 type Tree[T any] struct {
 	Children []*Tree[T] `json:"children"`
 	Value    T          `json:"value"`
+	parent   *Tree[T]
 }
 
 // New creates a new tree
@@ -18,13 +16,14 @@ func New[T any](value T) *Tree[T] {
 	}
 }
 
-// AddTree adds a sub-tree without children
+// Add adds a sub-tree without children
 func (t *Tree[T]) Add(child T) {
-	t.Children = append(t.Children, New(child))
+	t.AddTree(New(child))
 }
 
-// AddTree adds a sub-tree
+// AddTree adds a sub-tree, and makes t its parent
 func (t *Tree[T]) AddTree(child *Tree[T]) {
+	child.parent = t
 	t.Children = append(t.Children, child)
 }
 
@@ -48,23 +47,46 @@ func (t *Tree[T]) Crop(depth int, fn func(parent, child T)) {
 	}
 }
 
-// String converts the tree to a multiline string
-func (t *Tree[T]) String() string {
-	return PlainPrinter[T]{}.Print(t)
+// Filter removes all sub-trees for which keep returns false, except for the tree itself
+func (t *Tree[T]) Filter(keep func(T) bool) {
+	t.Prune(func(v T) bool { return !keep(v) }, nil, nil)
 }
 
-// SubTree selects a sub-tree using a path
-func SubTree[T any, P any](t *Tree[T], path []P, fn func(T, P) bool) (*Tree[T], error) {
-	if len(path) == 0 {
-		return t, nil
-	}
+// Prune removes all sub-trees for which drop returns true, except for the tree itself.
+// Children of removed sub-trees are not visited.
+//
+// The sub-trees dropped from a node are folded into a single new child of the node,
+// created by other from the value of the node. Fold is called with the value of this child for each dropped sub-tree.
+// With a nil other, dropped sub-trees are simply removed.
+func (t *Tree[T]) Prune(drop func(T) bool, other func(parent T) T, fold func(other T, dropped *Tree[T])) {
+	var rest *Tree[T]
+	children := t.Children[:0]
 	for _, child := range t.Children {
-		if fn(child.Value, path[0]) {
-			if len(path) == 1 {
-				return child, nil
-			}
-			return SubTree(child, path[1:], fn)
+		if !drop(child.Value) {
+			children = append(children, child)
+			continue
+		}
+		if other == nil {
+			continue
 		}
+		if rest == nil {
+			rest = New(other(t.Value))
+		}
+		fold(rest.Value, child)
+	}
+	for i := len(children); i < len(t.Children); i++ {
+		t.Children[i] = nil
 	}
-	return nil, fmt.Errorf("Can't select sub-tree. Path element '%v' not found in tree", path[0])
+	t.Children = children
+	for _, child := range t.Children {
+		child.Prune(drop, other, fold)
+	}
+	if rest != nil {
+		t.AddTree(rest)
+	}
+}
+
+// String converts the tree to a multiline string
+func (t *Tree[T]) String() string {
+	return PlainPrinter[T]{}.Print(t)
 }
@@ -71,3 +71,22 @@ func TestTreeCrop(t *testing.T) {
 	assert.Equal(t, 6, *tr.Value)
 	assert.Equal(t, 0, len(tr.Children))
 }
+
+func TestTreePrune(t *testing.T) {
+	tr := createWalkTree()
+	tr.Filter(func(v string) bool { return v != "b" })
+	assert.Equal(t, 1, len(tr.Children))
+	assert.Equal(t, "c", tr.Children[0].Value)
+
+	tr = createWalkTree()
+	folded := []string{}
+	tr.Prune(func(v string) bool { return v == "d" || v == "c" },
+		func(parent string) string { return parent + "-other" },
+		func(other string, dropped *Tree[string]) { folded = append(folded, other+":"+dropped.Value) })
+
+	assert.Equal(t, []string{"a-other:c", "b-other:d"}, folded)
+	assert.Equal(t, 2, len(tr.Children))
+	assert.Equal(t, "b", tr.Children[0].Value)
+	assert.Equal(t, "a-other", tr.Children[1].Value)
+	assert.Equal(t, []string{"e", "b-other"}, []string{tr.Children[0].Children[0].Value, tr.Children[0].Children[1].Value})
+}
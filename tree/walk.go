@@ -0,0 +1,120 @@
+package tree
+
+import (
+	"iter"
+)
+
+// WalkAction controls a walk, as returned by a Visitor
+type WalkAction int
+
+// Actions of a Visitor
+const (
+	Continue     WalkAction = iota // Continue the walk
+	SkipChildren                   // Skip the children of the node. Only effective before the children are visited
+	Stop                           // Stop the walk
+)
+
+// Node is a sub-tree visited during a walk, with its context
+type Node[T any] struct {
+	Tree *Tree[T]
+	// Ancestors of the node, starting with the root of the walk.
+	// Only valid during the visit, as the slice is reused. Copy it to keep it.
+	Ancestors []*Tree[T]
+}
+
+// Depth returns the depth of the node, relative to the root of the walk
+func (n Node[T]) Depth() int {
+	return len(n.Ancestors)
+}
+
+// Parent returns the parent of the node, or nil for the root of the walk
+func (n Node[T]) Parent() *Tree[T] {
+	if len(n.Ancestors) == 0 {
+		return nil
+	}
+	return n.Ancestors[len(n.Ancestors)-1]
+}
+
+// Visitor is called for nodes during a walk
+type Visitor[T any] func(n Node[T]) WalkAction
+
+// Walk visits the tree depth-first. Pre is called for each node before its children (pre-order),
+// post after its children (post-order). Either of them may be nil.
+//
+// If pre returns SkipChildren, the children of the node are not visited, but post is still called for the node.
+// If pre or post return Stop, the walk ends immediately.
+// Returns false if the walk was stopped.
+func (t *Tree[T]) Walk(pre, post Visitor[T]) bool {
+	return t.walk(pre, post, make([]*Tree[T], 0, 16))
+}
+
+func (t *Tree[T]) walk(pre, post Visitor[T], ancestors []*Tree[T]) bool {
+	n := Node[T]{Tree: t, Ancestors: ancestors}
+	action := Continue
+	if pre != nil {
+		action = pre(n)
+		if action == Stop {
+			return false
+		}
+	}
+	if action != SkipChildren {
+		ancestors = append(ancestors, t)
+		for _, child := range t.Children {
+			if !child.walk(pre, post, ancestors) {
+				return false
+			}
+		}
+		ancestors = ancestors[:len(ancestors)-1]
+	}
+	if post != nil {
+		return post(Node[T]{Tree: t, Ancestors: ancestors}) != Stop
+	}
+	return true
+}
+
+// All iterates over all sub-trees in pre-order, including the tree itself
+func (t *Tree[T]) All() iter.Seq[*Tree[T]] {
+	return func(yield func(*Tree[T]) bool) {
+		t.Walk(func(n Node[T]) WalkAction {
+			if !yield(n.Tree) {
+				return Stop
+			}
+			return Continue
+		}, nil)
+	}
+}
+
+// Leaves iterates over all sub-trees without children in pre-order
+func (t *Tree[T]) Leaves() iter.Seq[*Tree[T]] {
+	return func(yield func(*Tree[T]) bool) {
+		t.Walk(func(n Node[T]) WalkAction {
+			if len(n.Tree.Children) == 0 && !yield(n.Tree) {
+				return Stop
+			}
+			return Continue
+		}, nil)
+	}
+}
+
+// WithPaths iterates over all sub-trees in pre-order, together with their slash-separated paths.
+// Paths are made of the names of the nodes, from function name, relative to the tree itself.
+// The path of the tree itself is ".".
+func (t *Tree[T]) WithPaths(name func(T) string) iter.Seq2[string, *Tree[T]] {
+	return func(yield func(string, *Tree[T]) bool) {
+		paths := []string{"."}
+		t.Walk(func(n Node[T]) WalkAction {
+			depth := n.Depth()
+			if depth > 0 {
+				p := name(n.Tree.Value)
+				if depth > 1 {
+					p = paths[depth-1] + "/" + p
+				}
+				paths = append(paths[:depth], p)
+			}
+			if !yield(paths[depth], n.Tree) {
+				return Stop
+			}
+			return Continue
+		}, nil)
+	}
+}
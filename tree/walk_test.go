@@ -0,0 +1,97 @@
+package tree
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createWalkTree creates the tree a(b(d, e), c)
+func createWalkTree() *Tree[string] {
+	tr := New("a")
+	b := New("b")
+	b.Add("d")
+	b.Add("e")
+	tr.AddTree(b)
+	tr.Add("c")
+	return tr
+}
+
+func TestTreeWalk(t *testing.T) {
+	tr := createWalkTree()
+
+	pre := []string{}
+	post := []string{}
+	completed := tr.Walk(
+		func(n Node[string]) WalkAction {
+			parent := "-"
+			if p := n.Parent(); p != nil {
+				parent = p.Value
+			}
+			pre = append(pre, fmt.Sprintf("%s%d%s", n.Tree.Value, n.Depth(), parent))
+			return Continue
+		},
+		func(n Node[string]) WalkAction {
+			post = append(post, n.Tree.Value)
+			return Continue
+		})
+	assert.True(t, completed)
+	assert.Equal(t, []string{"a0-", "b1a", "d2b", "e2b", "c1a"}, pre)
+	assert.Equal(t, []string{"d", "e", "b", "c", "a"}, post)
+
+	pre = []string{}
+	post = []string{}
+	completed = tr.Walk(
+		func(n Node[string]) WalkAction {
+			pre = append(pre, n.Tree.Value)
+			if n.Tree.Value == "b" {
+				return SkipChildren
+			}
+			return Continue
+		},
+		func(n Node[string]) WalkAction {
+			post = append(post, n.Tree.Value)
+			return Continue
+		})
+	assert.True(t, completed)
+	assert.Equal(t, []string{"a", "b", "c"}, pre)
+	assert.Equal(t, []string{"b", "c", "a"}, post)
+
+	post = []string{}
+	completed = tr.Walk(nil,
+		func(n Node[string]) WalkAction {
+			post = append(post, n.Tree.Value)
+			if n.Tree.Value == "e" {
+				return Stop
+			}
+			return Continue
+		})
+	assert.False(t, completed)
+	assert.Equal(t, []string{"d", "e"}, post)
+}
+
+func TestTreeIterators(t *testing.T) {
+	tr := createWalkTree()
+
+	all := []string{}
+	for n := range tr.All() {
+		all = append(all, n.Value)
+	}
+	assert.Equal(t, []string{"a", "b", "d", "e", "c"}, all)
+
+	leaves := []string{}
+	for n := range tr.Leaves() {
+		leaves = append(leaves, n.Value)
+	}
+	assert.Equal(t, []string{"d", "e", "c"}, leaves)
+
+	paths := []string{}
+	for p, n := range tr.WithPaths(func(v string) string { return v }) {
+		paths = append(paths, p)
+		if n.Value == "e" {
+			break
+		}
+	}
+	assert.Equal(t, []string{".", "b", "b/d", "b/e"}, paths)
+}
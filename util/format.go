@@ -3,6 +3,8 @@ package util
 import (
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -67,6 +69,56 @@ func FormatUnitsSimple(b int64, unit string) string {
 	return fmt.Sprintf("%.1f %s%s", value, unitPrefixes[exp1k], unit)
 }
 
+// ParseUnits parses numbers with unit prefixes, like "500", "1.5M" or "10 kB", with an optional unit
+func ParseUnits(s string, unit string) (int64, error) {
+	str := strings.TrimSpace(s)
+	str = strings.TrimSuffix(str, unit)
+	str = strings.TrimSpace(str)
+
+	fac := 1.0
+	for i := len(unitPrefixes) - 1; i > 0; i-- {
+		if p := unitPrefixes[i]; strings.HasSuffix(str, p) || strings.HasSuffix(str, strings.ToLower(p)) {
+			str = strings.TrimSpace(str[:len(str)-len(p)])
+			fac = math.Pow(10, float64(i*3))
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(str, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid value '%s'", s)
+	}
+	return int64(math.Round(value * fac)), nil
+}
+
+// ageUnits are the units of ages in addition to those of time.ParseDuration, for days, weeks and years
+var ageUnits = []struct {
+	suffix string
+	unit   time.Duration
+}{
+	{"d", 24 * time.Hour},
+	{"w", 7 * 24 * time.Hour},
+	{"y", 365 * 24 * time.Hour},
+}
+
+// ParseAge parses ages like "7d", "2w" or "1.5y", or durations like "12h" as accepted by time.ParseDuration
+func ParseAge(s string) (time.Duration, error) {
+	str := strings.TrimSpace(s)
+	for _, u := range ageUnits {
+		if strings.HasSuffix(str, u.suffix) {
+			value, err := strconv.ParseFloat(strings.TrimSpace(str[:len(str)-len(u.suffix)]), 64)
+			if err != nil || value < 0 {
+				return 0, fmt.Errorf("invalid age '%s'", s)
+			}
+			return time.Duration(value * float64(u.unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age '%s'", s)
+	}
+	return d, nil
+}
+
 // FormatDuration prints a foratter duration to a Writer
 func FormatDuration(from time.Time, to time.Time) string {
 	if from.IsZero() || to.IsZero() {
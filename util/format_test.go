@@ -2,6 +2,7 @@ package util
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -29,3 +30,49 @@ func TestFormatUnits(t *testing.T) {
 	assert.Equal(t, "1.0 TB", FormatUnits(1e12, "B"))
 	assert.Equal(t, "1.0 PB", FormatUnits(1e15, "B"))
 }
+
+func TestParseUnits(t *testing.T) {
+	for str, value := range map[string]int64{
+		"0":       0,
+		"123":     123,
+		"123 B":   123,
+		"1.5k":    1500,
+		"12 kB":   12000,
+		"1M":      1e6,
+		"1.2MB":   1.2e6,
+		"2 GB":    2e9,
+		"1T":      1e12,
+		" 100m  ": 100e6,
+	} {
+		v, err := ParseUnits(str, "B")
+		assert.Nil(t, err)
+		assert.Equal(t, value, v, str)
+	}
+
+	for _, str := range []string{"", "k", "abc", "1 X", "-5"} {
+		_, err := ParseUnits(str, "B")
+		assert.NotNil(t, err, str)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	day := 24 * time.Hour
+	for str, value := range map[string]time.Duration{
+		"0":     0,
+		"12h":   12 * time.Hour,
+		"90m":   90 * time.Minute,
+		"7d":    7 * day,
+		"1.5d":  36 * time.Hour,
+		"2 w":   14 * day,
+		" 1y  ": 365 * day,
+	} {
+		v, err := ParseAge(str)
+		assert.Nil(t, err)
+		assert.Equal(t, value, v, str)
+	}
+
+	for _, str := range []string{"", "d", "abc", "5", "1x", "-5d", "-1h"} {
+		_, err := ParseAge(str)
+		assert.NotNil(t, err, str)
+	}
+}